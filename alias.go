@@ -0,0 +1,59 @@
+package getopt
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// Hide leaves the named option out of usage, documentation, and completion
+// scripts.  It still works.
+func (p *Parser) Hide(name string) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	spec.hidden = true
+	return nil
+}
+
+// Deprecate makes old another name for the option named replacement, such
+// as after renaming an option.  replacement must be the name from the
+// option's descriptor, not a negated name.  old is not shown in usage,
+// documentation, or completion scripts.  Using it adds a warning naming the
+// replacement, which is written to [Config.Warnings] when the values are
+// committed.  If the option is negatable, the negated forms of old work
+// too.
+func (p *Parser) Deprecate(old, replacement string) error {
+	oc := p.oc
+	spec, err := p.spec(replacement)
+	if err != nil {
+		return err
+	}
+	if oc.key(replacement) != oc.key(spec.name) {
+		// Negated names are paired with old's negated names below.
+		return errors.New("replacement is not the option's name")
+	}
+	if err := oc.checkNameConflict(old, spec.negatable); err != nil {
+		return err
+	}
+
+	// Pair each form of old with the same form of the option's name.
+	olds := oc.names(&optionSpec{name: old, negatable: spec.negatable})
+	news := oc.names(spec)
+	for i, name := range olds {
+		h, _ := oc.lookup(news[i])
+		oc.setHandler(name, h)
+		oc.byName[oc.key(name)] = spec
+		oc.deprecated[oc.key(name)] = news[i]
+	}
+	return nil
+}
+
+// warnings returns where warnings are written.
+func (oc *optionCollection) warnings() io.Writer {
+	if oc.config.Warnings != nil {
+		return oc.config.Warnings
+	}
+	return os.Stderr
+}
@@ -0,0 +1,98 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHide_Base(t *testing.T) {
+	var b bytes.Buffer
+	debug := false
+	verbose := false
+
+	p, err := NewParser(Config{Name: "prog"}, "debug", &debug, "verbose", &verbose)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Hide("debug"))
+	assert.NoError(t, p.WriteUsage(&b))
+	_, err = p.GetOptions([]string{"--debug"})
+
+	assert.NoError(t, err)
+	assert.True(t, debug)
+	assert.Equal(t, b.String(), "Usage: prog [options] [--] [args...]\n\nOptions:\n  --verbose\n")
+}
+
+func TestHide_Unknown(t *testing.T) {
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Hide("other"), "Arg other not recognized")
+}
+
+func TestDeprecate_Base(t *testing.T) {
+	var warnings bytes.Buffer
+	args := []string{"--colour", "red", "--colour=blue", "rest"}
+	color := ""
+
+	p, err := NewParser(Config{Warnings: &warnings}, "color=s", &color)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Deprecate("colour", "color"))
+	r, err := p.Parse(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, r.Args, args[3:])
+	assert.Equal(t, color, "blue")
+	assert.True(t, r.Seen("color"))
+	assert.Equal(t, warnings.String(), "warning: option --colour is deprecated, use --color\n")
+}
+
+// Warnings are only written when the plan is applied.
+func TestDeprecate_Plan(t *testing.T) {
+	var warnings bytes.Buffer
+	color := false
+
+	p, err := NewParser(Config{Warnings: &warnings}, "color!", &color)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Deprecate("colour", "color"))
+	plan, err := p.Plan([]string{"--colour", "--no-colour"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, plan.Warnings, []string{
+		"option --colour is deprecated, use --color",
+		"option --no-colour is deprecated, use --no-color",
+	})
+	assert.Empty(t, warnings.String())
+	assert.NoError(t, plan.Apply())
+	assert.False(t, color)
+	assert.Contains(t, warnings.String(), "use --no-color\n")
+}
+
+// The old name is not shown anywhere.
+func TestDeprecate_Hidden(t *testing.T) {
+	var usage, completion bytes.Buffer
+	color := ""
+
+	p, err := NewParser(Config{Name: "prog"}, "color=s", &color)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Deprecate("colour", "color"))
+	assert.NoError(t, p.WriteUsage(&usage))
+	assert.NoError(t, p.WriteCompletion(&completion, "bash", "prog"))
+
+	assert.NotContains(t, usage.String(), "colour")
+	assert.NotContains(t, completion.String(), "colour")
+}
+
+func TestDeprecate_Errors(t *testing.T) {
+	color := ""
+	size := 0
+	bold := false
+
+	p, err := NewParser(Config{}, "color=s", &color, "size=i", &size, "bold!", &bold)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Deprecate("colour", "other"), "Arg other not recognized")
+	assert.ErrorContains(t, p.Deprecate("size", "color"), "option already exists")
+	assert.ErrorContains(t, p.Deprecate("nobolded", "nobold"), "replacement is not the option's name")
+	_, ok := p.oc.lookupSpec("nobolded")
+	assert.False(t, ok)
+}
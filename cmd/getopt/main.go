@@ -0,0 +1,387 @@
+// Getopt parses options for shell scripts, using the same descriptors and
+// rules as the getopt package.
+//
+// Usage:
+//
+//	eval "$(getopt [--json] [--name=prog] --spec=desc... -- "$@")"
+//
+// Each --spec is a descriptor, such as "length=i" or "verbose!".  The
+// arguments after "--" are parsed, and the result is printed as shell
+// assignments suitable for eval:
+//
+//	length=10
+//	verbose=1
+//	set -- 'rest'
+//
+// Option names become variable names, with "-" replaced by "_".  Names
+// which still can't be variable names, such as "2fast", are an error
+// unless --json is given, as are names which would change variables the
+// shell uses, such as PATH or IFS, or variables set in the environment,
+// and names which two options would share, such as "file-name" and
+// "file_name".  Options
+// which were not given are not assigned, so defaults can be set before the
+// eval.  Counting options are always assigned, starting from 0, and array
+// options are always assigned as bash arrays.  Booleans are 1 or 0.  A
+// descriptor without a type, such as "verbose", is a boolean flag, and an
+// array without a type holds strings.
+//
+// With --json, the result is printed as a JSON object with "options" and
+// "args" members instead.  Numbers are written in a standard form, such as
+// 5 for "+5", and infinite or NaN floats are an error.
+//
+// If the arguments can't be parsed, an error is printed to stderr, and the
+// exit status is 1.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dshess/getopt"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// option holds the result for one --spec.
+type option struct {
+	name  string
+	kind  byte // 'b', 'i', 'f', or 's'
+	array bool
+
+	// Scalars are bound as a scalarValue, so only given options are
+	// reported.
+	scalar *scalarValue
+	count  int
+	ints   []int
+	floats []float64
+	strs   []string
+}
+
+// scalarValue is a flag.Value which remembers whether it was set.
+type scalarValue struct {
+	kind     byte
+	optional bool
+	set      bool
+	value    string
+}
+
+func (v *scalarValue) IsBoolFlag() bool {
+	return v.kind == 'b'
+}
+
+func (v *scalarValue) String() string {
+	return v.value
+}
+
+func (v *scalarValue) Set(s string) error {
+	switch v.kind {
+	case 'b':
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		s = "0"
+		if b {
+			s = "1"
+		}
+	case 'i', 'f':
+		// Numbers are stored in a standard form, so "+5" is "5".
+		if s == "" && v.optional {
+			s = "0"
+		} else if v.kind == 'i' {
+			i, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			s = strconv.Itoa(i)
+		} else {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			s = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	v.set = true
+	v.value = s
+	return nil
+}
+
+// bind turns a --spec into a descriptor and pointer for the getopt
+// package.
+func bind(spec string) (*option, []any, error) {
+	d, err := getopt.ParseDescriptor(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	name, kind := d.Name, string(d.Type)
+	sep := "="
+	if d.Optional {
+		sep = ":"
+	}
+	modifier := ""
+	if d.Modifier != 0 {
+		modifier = string(d.Modifier)
+	}
+	if d.Type == '?' && modifier == "@" {
+		kind = "s"
+	} else if d.Type == '?' {
+		kind = "b"
+	}
+
+	o := &option{name: name, kind: kind[0]}
+	switch modifier {
+	case "+":
+		if kind != "b" && kind != "i" {
+			return nil, nil, errors.New("descriptor " + strconv.Quote(spec) + " type mismatch")
+		}
+		o.kind = 'i'
+		return o, []any{name + "+", &o.count}, nil
+	case "@":
+		o.array = true
+		// Empty arrays are reported as empty, not null.
+		switch kind {
+		case "i":
+			o.ints = []int{}
+			return o, []any{spec, &o.ints}, nil
+		case "f":
+			o.floats = []float64{}
+			return o, []any{spec, &o.floats}, nil
+		case "s":
+			o.strs = []string{}
+			return o, []any{spec, &o.strs}, nil
+		}
+		return nil, nil, errors.New("descriptor " + strconv.Quote(spec) + " type mismatch")
+	}
+
+	// A flag.Value is typed as a string or a boolean, so the descriptor
+	// gives the type only to the value.
+	o.scalar = &scalarValue{kind: o.kind, optional: sep == ":"}
+	desc := name + modifier
+	if sep == ":" {
+		desc = name + ":s" + modifier
+	}
+	if o.kind != 'b' && sep != ":" {
+		desc = name + "=s" + modifier
+	}
+	return o, []any{desc, o.scalar}, nil
+}
+
+// shellQuote quotes s as a single word for the shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func variable(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// isIdentifier reports whether s can be a shell variable name.
+func isIdentifier(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+			(i > 0 && '0' <= c && c <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// specialVariables are shell variables which change how the shell behaves,
+// or which it sets itself, so options must not assign them.
+var specialVariables = map[string]bool{
+	"CDPATH": true, "COLUMNS": true, "DIRSTACK": true, "ENV": true,
+	"EUID": true, "FUNCNAME": true, "GROUPS": true, "HISTFILE": true,
+	"HISTSIZE": true, "HOME": true, "HOSTNAME": true, "IFS": true,
+	"LANG": true, "LINENO": true, "LINES": true, "MAIL": true,
+	"MAILPATH": true, "OLDPWD": true, "OPTARG": true, "OPTERR": true,
+	"OPTIND": true, "PATH": true, "PIPESTATUS": true, "POSIXLY_CORRECT": true,
+	"PPID": true, "PS1": true, "PS2": true, "PS3": true, "PS4": true,
+	"PWD": true, "RANDOM": true, "REPLY": true, "SECONDS": true,
+	"SHELL": true, "SHELLOPTS": true, "SHLVL": true, "TMOUT": true,
+	"UID": true,
+}
+
+// checkVariable returns an error if the option name can't safely be
+// assigned as the shell variable v: if v is not a variable name, is
+// special to the shell, or is set in the environment, or another option in
+// vars already uses it.  Otherwise v is added to vars.
+func checkVariable(vars map[string]string, name, v string) error {
+	switch {
+	case !isIdentifier(v):
+		return fmt.Errorf("option name %q can't be a shell variable", name)
+	case specialVariables[v] || strings.HasPrefix(v, "BASH") ||
+		strings.HasPrefix(v, "LC_") || strings.HasPrefix(v, "COMP_"):
+		return fmt.Errorf("option name %q would change the shell variable %s", name, v)
+	case isEnv(v):
+		return fmt.Errorf("option name %q would change the environment variable %s", name, v)
+	case vars[v] != "":
+		return fmt.Errorf("options %q and %q both assign the shell variable %s", vars[v], name, v)
+	}
+	vars[v] = name
+	return nil
+}
+
+// isEnv reports whether v is set in the environment.
+func isEnv(v string) bool {
+	_, ok := os.LookupEnv(v)
+	return ok
+}
+
+func writeShell(w io.Writer, options []*option, rest []string) error {
+	var b strings.Builder
+
+	for _, o := range options {
+		v := variable(o.name)
+		var words []string
+		switch {
+		case o.scalar != nil:
+			if o.scalar.set {
+				fmt.Fprintf(&b, "%s=%s\n", v, shellQuote(o.scalar.value))
+			}
+			continue
+		case o.array:
+			for _, i := range o.ints {
+				words = append(words, strconv.Itoa(i))
+			}
+			for _, f := range o.floats {
+				words = append(words, strconv.FormatFloat(f, 'g', -1, 64))
+			}
+			for _, s := range o.strs {
+				words = append(words, shellQuote(s))
+			}
+		default:
+			fmt.Fprintf(&b, "%s=%d\n", v, o.count)
+			continue
+		}
+		fmt.Fprintf(&b, "%s=(%s)\n", v, strings.Join(words, " "))
+	}
+
+	var words []string
+	for _, s := range rest {
+		words = append(words, shellQuote(s))
+	}
+	b.WriteString(strings.TrimSpace("set -- "+strings.Join(words, " ")) + "\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeJSON(w io.Writer, options []*option, rest []string) error {
+	if rest == nil {
+		rest = []string{}
+	}
+	values := make(map[string]any)
+	for _, o := range options {
+		switch {
+		case o.scalar != nil:
+			if !o.scalar.set {
+				continue
+			}
+			switch o.kind {
+			case 'b':
+				values[o.name] = o.scalar.value == "1"
+			case 'i':
+				values[o.name] = json.Number(o.scalar.value)
+			case 'f':
+				f, _ := strconv.ParseFloat(o.scalar.value, 64)
+				if math.IsInf(f, 0) || math.IsNaN(f) {
+					return fmt.Errorf("option %s: %s can't be written as JSON", o.name, o.scalar.value)
+				}
+				values[o.name] = json.Number(o.scalar.value)
+			default:
+				values[o.name] = o.scalar.value
+			}
+		case o.array && o.kind == 'i':
+			values[o.name] = o.ints
+		case o.array && o.kind == 'f':
+			values[o.name] = o.floats
+		case o.array:
+			values[o.name] = o.strs
+		default:
+			values[o.name] = o.count
+		}
+	}
+
+	out, err := json.Marshal(struct {
+		Options map[string]any `json:"options"`
+		Args    []string       `json:"args"`
+	}{values, rest})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var specs []string
+	var asJSON bool
+	name := "getopt"
+
+	p, err := getopt.NewParser(getopt.Config{Help: true, Name: "getopt", Output: stdout},
+		"spec=s@", &specs, "json", &asJSON, "name=s", &name)
+	if err != nil {
+		fmt.Fprintln(stderr, "getopt:", err)
+		return 1
+	}
+	p.Describe("spec", "Descriptor for an option to parse.")
+	p.Describe("json", "Print the result as JSON.")
+	p.Describe("name", "Program name for error messages.")
+	args, err = p.GetOptions(args)
+	if errors.Is(err, getopt.ErrHelp) {
+		return 0
+	} else if err != nil {
+		fmt.Fprintln(stderr, "getopt:", err)
+		return 1
+	}
+
+	var options []*option
+	var a []any
+	vars := make(map[string]string)
+	for _, spec := range specs {
+		o, pair, err := bind(spec)
+		if err != nil {
+			fmt.Fprintln(stderr, "getopt:", err)
+			return 1
+		}
+		if asJSON {
+			// Any name can be a JSON member.
+		} else if err := checkVariable(vars, o.name, variable(o.name)); err != nil {
+			fmt.Fprintln(stderr, "getopt:", err)
+			return 1
+		}
+		options = append(options, o)
+		a = append(a, pair...)
+	}
+	parser, err := getopt.NewParser(getopt.Config{}, a...)
+	if err != nil {
+		fmt.Fprintln(stderr, "getopt:", err)
+		return 1
+	}
+	rest, err := parser.GetOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	if asJSON {
+		err = writeJSON(stdout, options, rest)
+	} else {
+		err = writeShell(stdout, options, rest)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "getopt:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+)
+
+func runTest(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestShell_Base(t *testing.T) {
+	status, out, errs := runTest(
+		"--spec=length=i", "--spec", "verbose!", "--spec=file-name=s",
+		"--", "--length", "10", "--noverbose", "--file-name=it's", "rest",
+	)
+
+	assert.Equal(t, status, 0)
+	assert.Empty(t, errs)
+	assert.Equal(t, out, "length='10'\nverbose='0'\nfile_name='it'\\''s'\nset -- 'rest'\n")
+}
+
+// Options which weren't given aren't assigned.
+func TestShell_NotGiven(t *testing.T) {
+	status, out, _ := runTest("--spec=length=i", "--spec=verbose", "--")
+
+	assert.Equal(t, status, 0)
+	assert.Equal(t, out, "set --\n")
+}
+
+// Counts and arrays are always assigned.
+func TestShell_CountAndArray(t *testing.T) {
+	status, out, _ := runTest(
+		"--spec=v+", "--spec=tag@", "--spec=size=i@",
+		"--", "--v", "--v", "--tag", "a b", "--tag=c",
+	)
+
+	assert.Equal(t, status, 0)
+	assert.Equal(t, out, "v=2\ntag=('a b' 'c')\nsize=()\nset --\n")
+}
+
+func TestShell_Optional(t *testing.T) {
+	status, out, _ := runTest("--spec=level:i", "--", "--level", "--", "x")
+
+	assert.Equal(t, status, 0)
+	assert.Equal(t, out, "level='0'\nset -- 'x'\n")
+}
+
+func TestShell_Eval(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+	_, out, _ := runTest(
+		"--spec=length=i", "--spec=tag@", "--",
+		"--length=5", "--tag", "a'b", "--tag", "c d", "x y",
+	)
+	script := out + `echo "$length ${#tag[@]} ${tag[0]} ${tag[1]} $# $1"`
+
+	got, err := exec.Command(bash, "-c", script).Output()
+
+	assert.NoError(t, err)
+	assert.Equal(t, string(got), "5 2 a'b c d 1 x y\n")
+}
+
+func TestJSON_Base(t *testing.T) {
+	status, out, _ := runTest(
+		"--json", "--spec=length=i", "--spec=verbose", "--spec=scale=f",
+		"--spec=tag@", "--spec=name=s",
+		"--", "--length", "10", "--verbose", "--scale=2.5", "rest",
+	)
+
+	assert.Equal(t, status, 0)
+	assert.Equal(t, out, `{"options":{"length":10,"scale":2.5,"tag":[],"verbose":true},"args":["rest"]}`+"\n")
+}
+
+// Numbers in any form Set accepts are written as JSON numbers.
+func TestJSON_Numbers(t *testing.T) {
+	status, out, errs := runTest(
+		"--json", "--spec=n=i", "--spec=x=f", "--spec=y=f",
+		"--", "--n=+5", "--x=.5", "--y=1e6",
+	)
+
+	assert.Equal(t, status, 0)
+	assert.Empty(t, errs)
+	assert.Equal(t, out, `{"options":{"n":5,"x":0.5,"y":1e+06},"args":[]}`+"\n")
+}
+
+func TestJSON_NotFinite(t *testing.T) {
+	for _, arg := range []string{"--x=inf", "--x=NaN"} {
+		status, out, errs := runTest("--json", "--spec=x=f", "--", arg)
+
+		assert.Equal(t, status, 1)
+		assert.Empty(t, out)
+		assert.Contains(t, errs, "can't be written as JSON")
+	}
+}
+
+func TestError_BadArgs(t *testing.T) {
+	status, out, errs := runTest("--name=myscript", "--spec=length=i", "--", "--length=x")
+
+	assert.Equal(t, status, 1)
+	assert.Empty(t, out)
+	assert.Contains(t, errs, "myscript: invalid value \"x\"")
+}
+
+func TestError_BadSpec(t *testing.T) {
+	status, out, errs := runTest("--spec=length=q", "--")
+
+	assert.Equal(t, status, 1)
+	assert.Empty(t, out)
+	assert.Contains(t, errs, "getopt: descriptor not understood: expected type b, i, f, or s at offset 7 in \"length=q\"")
+}
+
+// Names have to be shell variables, except in JSON.
+func TestError_BadName(t *testing.T) {
+	status, out, errs := runTest("--spec=2fast", "--", "--2fast")
+
+	assert.Equal(t, status, 1)
+	assert.Empty(t, out)
+	assert.Contains(t, errs, "getopt: option name \"2fast\" can't be a shell variable")
+
+	status, out, _ = runTest("--json", "--spec=2fast", "--", "--2fast")
+
+	assert.Equal(t, status, 0)
+	assert.Equal(t, out, `{"options":{"2fast":true},"args":[]}`+"\n")
+}
+
+func TestError_Conflict(t *testing.T) {
+	status, _, errs := runTest("--spec=flag!", "--spec=noflag", "--")
+
+	assert.Equal(t, status, 1)
+	assert.Contains(t, errs, "getopt: option already exists")
+}
+
+// Options can't change variables the shell or the caller use.
+func TestError_Variables(t *testing.T) {
+	t.Setenv("GETOPT_TEST_VAR", "")
+
+	for spec, msg := range map[string]string{
+		"--spec=PATH=s":          `option name "PATH" would change the shell variable PATH`,
+		"--spec=IFS=s":           `option name "IFS" would change the shell variable IFS`,
+		"--spec=BASH_ENV=s":      `option name "BASH_ENV" would change the shell variable BASH_ENV`,
+		"--spec=GETOPT_TEST_VAR": `option name "GETOPT_TEST_VAR" would change the environment variable GETOPT_TEST_VAR`,
+	} {
+		status, out, errs := runTest(spec, "--")
+
+		assert.Equal(t, status, 1)
+		assert.Empty(t, out)
+		assert.Contains(t, errs, "getopt: "+msg)
+	}
+}
+
+// Two options can't assign the same variable.
+func TestError_SameVariable(t *testing.T) {
+	status, out, errs := runTest("--spec=file-name=s", "--spec=file_name=s", "--")
+
+	assert.Equal(t, status, 1)
+	assert.Empty(t, out)
+	assert.Contains(t, errs, `getopt: options "file-name" and "file_name" both assign the shell variable file_name`)
+
+	status, _, _ = runTest("--json", "--spec=file-name=s", "--spec=file_name=s", "--")
+	assert.Equal(t, status, 0)
+}
+
+func TestHelp(t *testing.T) {
+	status, out, _ := runTest("--help")
+
+	assert.Equal(t, status, 0)
+	assert.Contains(t, out, "Usage: getopt [options]")
+	assert.Contains(t, out, "--spec=string")
+}
@@ -0,0 +1,15 @@
+// Getoptcheck checks descriptors passed to getopt.GetOptions,
+// getopt.GetOSArgs, getopt.GetOSOptions, getopt.NewParser and
+// Parser.Positional.  It can be run directly, or by go vet:
+//
+//	go vet -vettool=$(which getoptcheck) ./...
+package main
+
+import (
+	"github.com/dshess/getopt/getoptcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(getoptcheck.Analyzer)
+}
@@ -0,0 +1,221 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// completeOption is the hidden option which generated completion scripts
+// use to ask the program for candidate values.
+const completeOption = "--__complete"
+
+// ErrCompletion is returned by [Parser.GetOptions] after it has answered a
+// completion request from a generated completion script.  The program
+// should exit successfully without doing anything else.
+var ErrCompletion = errors.New("completion request handled")
+
+// Completer registers f to generate candidate values for the named option
+// when completing the command line.  Completion scripts written after this
+// call ask the program for candidates by running it with the hidden
+// --__complete option, which [Parser.GetOptions] answers by writing f's
+// candidates which start with the word being completed to [Config.Output],
+// one per line, and returning [ErrCompletion].
+func (p *Parser) Completer(name string, f func(prefix string) []string) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if spec.argType == optionNoArg {
+		return errors.New("option takes no value")
+	}
+	spec.completer = f
+	return nil
+}
+
+func (p *Parser) hasCompleters() bool {
+	for _, spec := range p.oc.specs {
+		if spec.completer != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// complete answers "--__complete <option> [<prefix>]".
+func (p *Parser) complete(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("malformed completion request")
+	}
+	spec, ok := p.oc.lookupSpec(args[0])
+	if !ok || spec.completer == nil {
+		return errors.New("Arg " + args[0] + " has no completer")
+	}
+	prefix := ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+
+	w := p.output()
+	for _, c := range spec.completer(prefix) {
+		if strings.HasPrefix(c, prefix) {
+			if _, err := fmt.Fprintln(w, c); err != nil {
+				return err
+			}
+		}
+	}
+	return ErrCompletion
+}
+
+// WriteCompletion writes a completion script for the program prog to w.
+// shell must be "bash", "zsh", or "fish".  The script completes every
+// option name, including negated forms.  Values of string options complete
+// as file paths, and values of options with a [Parser.Completer] are
+// requested from the program itself.
+func (p *Parser) WriteCompletion(w io.Writer, shell, prog string) error {
+	var b strings.Builder
+
+	switch shell {
+	case "bash":
+		p.bashCompletion(&b, prog)
+	case "zsh":
+		p.zshCompletion(&b, prog)
+	case "fish":
+		p.fishCompletion(&b, prog)
+	default:
+		return errors.New("shell " + shell + " not supported")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Characters which can't appear in a shell function name.
+var nonIdentRe = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// shellQuote quotes s for use as a single word in any of the supported
+// shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (p *Parser) bashCompletion(b *strings.Builder, prog string) {
+	fn := "_" + nonIdentRe.ReplaceAllString(prog, "_") + "_complete"
+
+	var words []string
+	for _, spec := range p.oc.specs {
+		if spec.visibility() == Hidden {
+			continue
+		}
+		for _, name := range p.oc.names(spec) {
+			words = append(words, "--"+name)
+		}
+		if spec.short != "" {
+			words = append(words, "-"+spec.short)
+		}
+	}
+
+	fmt.Fprintf(b, "# bash completion for %s\n", prog)
+	fmt.Fprintf(b, "%s() {\n", fn)
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("\tlocal prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("\tcase \"$prev\" in\n")
+	for _, spec := range p.oc.specs {
+		if spec.visibility() == Hidden {
+			continue
+		}
+		// Optional values can't be told apart from arguments.
+		if spec.argType != optionRequiredArg {
+			continue
+		}
+		fmt.Fprintf(b, "\t--%s)\n", spec.name)
+		if spec.completer != nil {
+			fmt.Fprintf(b, "\t\tmapfile -t COMPREPLY < <(\"${COMP_WORDS[0]}\" %s %s \"$cur\" 2>/dev/null)\n",
+				completeOption, spec.name)
+		} else if spec.kind == 's' {
+			b.WriteString("\t\tmapfile -t COMPREPLY < <(compgen -f -- \"$cur\")\n")
+		}
+		b.WriteString("\t\treturn\n")
+		b.WriteString("\t\t;;\n")
+	}
+	b.WriteString("\tesac\n")
+	b.WriteString("\tif [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(b, "\t\tmapfile -t COMPREPLY < <(compgen -W %s -- \"$cur\")\n",
+		shellQuote(strings.Join(words, " ")))
+	b.WriteString("\telse\n")
+	b.WriteString("\t\tmapfile -t COMPREPLY < <(compgen -f -- \"$cur\")\n")
+	b.WriteString("\tfi\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(b, "complete -o filenames -F %s %s\n", fn, shellQuote(prog))
+}
+
+func (p *Parser) zshCompletion(b *strings.Builder, prog string) {
+	fmt.Fprintf(b, "#compdef %s\n", prog)
+	b.WriteString("\n_arguments -s \\\n")
+	for _, spec := range p.oc.specs {
+		if spec.visibility() == Hidden {
+			continue
+		}
+		// Options which may be given more than once.
+		repeat := ""
+		if spec.array || spec.counting {
+			repeat = "*"
+		}
+
+		action := " "
+		if spec.completer != nil {
+			action = fmt.Sprintf(`{compadd -- ${(f)"$(${words[1]} %s %s "$PREFIX" 2>/dev/null)"}}`,
+				completeOption, spec.name)
+		} else if spec.kind == 's' {
+			action = "_files"
+		}
+
+		for _, name := range p.oc.names(spec) {
+			var arg string
+			switch spec.argType {
+			case optionRequiredArg:
+				arg = "=:" + spec.name + ":" + action
+			case optionOptionalArg:
+				arg = "=::" + spec.name + ":" + action
+			}
+			fmt.Fprintf(b, "\t%s \\\n", shellQuote(repeat+"--"+name+arg))
+		}
+		if spec.short != "" {
+			fmt.Fprintf(b, "\t%s \\\n", shellQuote(repeat+"-"+spec.short))
+		}
+	}
+	b.WriteString("\t'*:argument:_files'\n")
+}
+
+func (p *Parser) fishCompletion(b *strings.Builder, prog string) {
+	fmt.Fprintf(b, "# fish completion for %s\n", prog)
+	for _, spec := range p.oc.specs {
+		if spec.visibility() == Hidden {
+			continue
+		}
+		for i, name := range p.oc.names(spec) {
+			fmt.Fprintf(b, "complete -c %s -l %s", shellQuote(prog), name)
+			if i == 0 && spec.short != "" {
+				fmt.Fprintf(b, " -s %s", spec.short)
+			}
+			// Negated names never take values.
+			if i == 0 && spec.argType != optionNoArg {
+				if spec.argType == optionRequiredArg {
+					b.WriteString(" -r")
+				}
+				if spec.completer != nil {
+					cmd := fmt.Sprintf("(%s %s %s (commandline -ct) 2>/dev/null)",
+						prog, completeOption, spec.name)
+					fmt.Fprintf(b, " -f -a %s", shellQuote(cmd))
+				} else if spec.kind == 's' {
+					b.WriteString(" -F")
+				} else {
+					b.WriteString(" -f")
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+}
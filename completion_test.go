@@ -0,0 +1,124 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func completionParser(t *testing.T, config Config) *Parser {
+	var flag bool
+	var num int
+	var file string
+	var target string
+
+	p, err := NewParser(config,
+		"flag!", &flag, "num", &num, "file=s", &file, "target", &target)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestCompletion_Bash(t *testing.T) {
+	p := completionParser(t, Config{})
+	var b bytes.Buffer
+
+	err := p.WriteCompletion(&b, "bash", "my-prog")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "_my_prog_complete() {")
+	assert.Contains(t, b.String(), "'--flag --noflag --no-flag --num --file --target'")
+	assert.Contains(t, b.String(), "\t--file)\n\t\tmapfile -t COMPREPLY < <(compgen -f")
+	assert.Contains(t, b.String(), "complete -o filenames -F _my_prog_complete 'my-prog'")
+}
+
+func TestCompletion_Zsh(t *testing.T) {
+	p := completionParser(t, Config{})
+	var b bytes.Buffer
+
+	err := p.WriteCompletion(&b, "zsh", "my-prog")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "#compdef my-prog\n")
+	assert.Contains(t, b.String(), "'--noflag'")
+	assert.Contains(t, b.String(), "'--num=:num: '")
+	assert.Contains(t, b.String(), "'--file=:file:_files'")
+}
+
+func TestCompletion_Fish(t *testing.T) {
+	p := completionParser(t, Config{})
+	var b bytes.Buffer
+
+	err := p.WriteCompletion(&b, "fish", "my-prog")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "complete -c 'my-prog' -l noflag\n")
+	assert.Contains(t, b.String(), "complete -c 'my-prog' -l num -r -f\n")
+	assert.Contains(t, b.String(), "complete -c 'my-prog' -l file -r -F\n")
+}
+
+func TestCompletion_UnknownShell(t *testing.T) {
+	p := completionParser(t, Config{})
+	var b bytes.Buffer
+
+	err := p.WriteCompletion(&b, "csh", "my-prog")
+
+	assert.ErrorContains(t, err, "not supported")
+	assert.Empty(t, b.String())
+}
+
+func TestCompletion_CompleterScript(t *testing.T) {
+	p := completionParser(t, Config{})
+	err := p.Completer("target", func(string) []string { return nil })
+	assert.NoError(t, err)
+	var b bytes.Buffer
+
+	err = p.WriteCompletion(&b, "bash", "my-prog")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "--__complete target \"$cur\"")
+}
+
+func TestCompletion_Request(t *testing.T) {
+	var b bytes.Buffer
+	p := completionParser(t, Config{Output: &b})
+	err := p.Completer("target", func(string) []string {
+		return []string{"alpha", "beta", "alpine"}
+	})
+	assert.NoError(t, err)
+	args := []string{"--__complete", "target", "al"}
+
+	a, err := p.GetOptions(args)
+
+	assert.ErrorIs(t, err, ErrCompletion)
+	assert.Equal(t, b.String(), "alpha\nalpine\n")
+	assert.Equal(t, a, args)
+}
+
+// Without completers, the hidden option is not recognized.
+func TestCompletion_RequestNoCompleters(t *testing.T) {
+	var b bytes.Buffer
+	p := completionParser(t, Config{Output: &b})
+	args := []string{"--__complete", "target", "al"}
+
+	a, err := p.GetOptions(args)
+
+	assert.ErrorContains(t, err, "not recognized")
+	assert.Empty(t, b.String())
+	assert.Equal(t, a, args)
+}
+
+func TestCompletion_CompleterNoValue(t *testing.T) {
+	p := completionParser(t, Config{})
+
+	err := p.Completer("flag", func(string) []string { return nil })
+
+	assert.ErrorContains(t, err, "option takes no value")
+}
+
+func TestCompletion_CompleterUnknown(t *testing.T) {
+	p := completionParser(t, Config{})
+
+	err := p.Completer("other", func(string) []string { return nil })
+
+	assert.ErrorContains(t, err, "not recognized")
+}
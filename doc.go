@@ -24,14 +24,24 @@ If the command is passed "--files=hello.world --length 10 --verbose rest",
 then after [GetOSOptions], data will be "hello.world", length will be 10,
 verbose will be true, and [os.Args][1:] will be []string{"rest"}.
 
+[GetOSOptions] changes [os.Args], which is global.  [GetOSArgs] returns the
+remaining arguments instead, and [GetOptions] parses any slice of arguments.
+
 # Command line flag syntax
 
-This code only handles --option style of options.  "--" ends option
-processing.  Boolean options can only be negatable or simple, with no
-parameters (so --option or --nooption).  Int, Float, or String options can
+Options are given as --option.  With [Config.SingleDash], -option works too,
+though negative numbers such as -5 are never options.  With [Config.Help],
+-h is short for --help.  There are no other short options, and no
+bundling.  "--" ends option processing.  Boolean options can only be
+negatable or simple, given as --option or --nooption, or with an explicit
+value such as --option=false.
+true, yes, on, and 1 are true, and false, no, off, and 0 are false.
+Counting options take no value.  Int, Float, or String options can
 be provided as --option=value or --option value.  Optional options deliver
 the provided value if the option is seen with no further arguments, or if
-the next argument itself looks like an option.
+the next argument itself looks like an option.  A number such as "-5" or
+"-1.5e3" is always taken as the value of an int or float option, even if it
+looks like an option.
 
 # Option descriptors
 
@@ -41,7 +51,8 @@ type is not compatible with the descriptor.  It is also an error if
 multiple pointers to the same variable are provided.
 
   - "flag", &boolValue - --flag sets boolValue to true
-  - "flag!", &boolValue - --flag sets true, --noflag sets false
+  - "flag!", &boolValue - --flag sets true, --noflag or --no-flag sets
+    false
   - "count+", &intValue - each --count increments intValue
   - "value=i", &intValue - --value 15 or --value=15 sets intValue to 15
   - "value:i", &intValue - flat with optional value, if no value is
@@ -55,6 +66,17 @@ multiple pointers to the same variable are provided.
     version, or drop =f to infer the type.
   - "value=s", "value=s@", "value:s" with string-typed pointer for string
     version, or drop =s to infer the type.
+  - "value", flagValue - any [flag.Value] takes a string which is passed to
+    its Set method when committed.  Values for types holding a single
+    bool, number, or string, such as those from the flag package, are
+    tried on a new variable first, so invalid values are reported before
+    anything changes.  Other types only report invalid values when
+    committed, after earlier changes have been made.  A boolean
+    flag.Value takes no value, and may be negatable.
+  - "<>", func(arg string) error - called with each non-option argument,
+    in order with the changes options make, so it sees the values options
+    had at that point.  Options may follow non-option arguments, and
+    nothing remains after parsing.
 
 Descriptors in the style of "value=s" are more in the style of
 Getopt::Long, because Perl's typing is different than Go's.  Perl can infer
@@ -62,6 +84,138 @@ array versus scalar, but not int versus string.  Go can infer int vs
 string, so it may make sense to not use typing in the descriptor.  OTOH,
 the descriptor makes the type clear in context.
 
+# Parser settings
+
+[GetOptions] uses default settings.  To change them, build a [Parser] with
+[NewParser] and a [Config], then call its GetOptions method:
+
+	p, err := NewParser(Config{IgnoreCase: true}, "verbose", &verbose)
+
+The available settings are:
+
+  - IgnoreCase - match option names without regard to case, so --Verbose
+    matches "verbose".  Names which differ only in case conflict.
+  - SingleDash - also accept -name, -name=value, and -noname.  A
+    single-dash argument always names a long option, and "-" alone is not
+    an option.
+  - Output - where the parser writes output, such as help or answers to
+    completion requests.  Defaults to [os.Stdout].
+  - Warnings - where the parser writes warnings, such as for deprecated
+    options.  Defaults to [os.Stderr].
+  - Stdin - where values given as "-" are read, for options using
+    [Parser.Indirect].  Defaults to [os.Stdin].
+  - Help - add --help and -h, which print usage to Output and return
+    [ErrHelp] without committing any values.
+  - Version - if set, add --version, which prints Name and Version to
+    Output and returns [ErrVersion] without committing any values.
+  - Name - the program name shown in usage.  Defaults to the base name of
+    [os.Args][0].
+  - NegationPrefixes - prefixes which make names setting negatable options
+    to false.  Defaults to "no" and "no-".
+  - AffirmationPrefixes - prefixes which make more names setting negatable
+    options to true, such as "enable-" to pair with "disable-".
+  - RepeatPolicy - what happens when an option holding one value is given
+    more than once: the last value wins, the first value wins, or it is an
+    error.  [Parser.SetRepeatPolicy] sets it for one option.
+
+# Lists
+
+[Parser.Split] lets each value of an array option be a list, so --tags a,b,c
+appends three elements, each converted as if given separately:
+
+	err := p.Split("tags", ",", SkipEmpty)
+
+A backslash before the separator makes it part of an element.  The
+[EmptyPolicy] says whether empty elements are kept, skipped, or rejected.
+
+Values given for an array option are appended to whatever the slice held,
+including defaults.  [Parser.SetArrayPolicy] changes that, so the first value
+replaces the defaults, or an empty value as in --conf= empties the slice.
+
+# Values from files
+
+[Parser.Indirect] lets an option's values come from elsewhere, keeping
+secrets out of process listings.  --token=@path reads the file at path,
+--token=- reads stdin, and --token=env:NAME reads the environment variable
+NAME.  Trailing newlines are trimmed, and the value is then converted as
+if it had been given directly.
+
+[Parser.Sensitive] marks an option as secret, so its values are shown as
+"[redacted]" in errors, usage defaults, results, and assignments.
+
+# Positional parameters
+
+[Parser.Positional] declares typed parameters for the arguments remaining
+after options, using the same descriptors and pointers as options:
+
+	err := p.Positional("src=s", &src, "count=i?", &count, "files=s@", &files)
+
+A trailing "?" makes a parameter optional, and an array parameter takes
+the rest of the arguments.  Too few or too many arguments is an error,
+such as "expected at most 2 arguments".  Parameters are shown in the usage
+synopsis, such as "src [count] [files...]".
+
+# Results
+
+[Parser.Parse] works like GetOptions, but returns a [Result] which reports,
+for each option, whether it was given or left at its default, how many
+times it was given, the values given, and where in the arguments.
+
+[Parser.Plan] goes further, and changes nothing.  The returned [Plan] lists
+each pending assignment, with its option, target, value, and operation,
+for inspection or logging.  [Plan.Apply] then makes the changes.
+
+# The flag package
+
+[FromFlagSet] turns the flags defined in a [flag.FlagSet] into descriptors
+and pointers, so they can be parsed with the rules of this package.
+[Parser.ToFlagSet] goes the other way, defining a flag for each option.
+
+# Shell scripts
+
+The getopt command in cmd/getopt brings the same descriptors to shell
+scripts, printing the result as shell assignments or JSON:
+
+	eval "$(getopt --spec 'length=i' --spec 'verbose!' -- "$@")"
+
+Tools like it which work with descriptors can split them with
+[ParseDescriptor], so they follow the same grammar as this package.
+
+# Static checking
+
+The getoptcheck package provides an analyzer which checks literal
+descriptors passed to [GetOptions], [GetOSArgs], [GetOSOptions], [NewParser]
+and [Parser.Positional] against the types of their pointers, so mistakes
+are caught before the program runs:
+
+	go install github.com/dshess/getopt/cmd/getoptcheck@latest
+	go vet -vettool=$(which getoptcheck) ./...
+
+# Completion
+
+[Parser.WriteCompletion] writes a bash, zsh, or fish completion script
+covering every option name, including negated forms.  String values
+complete as file paths.  [Parser.Completer] registers a function which
+generates values for an option.  The script gets those values by running
+the program with a hidden --__complete option, which [Parser.GetOptions]
+answers before returning [ErrCompletion].
+
+# Documentation
+
+[Parser.Describe] attaches help text to an option.  [Parser.WriteUsage]
+writes the usage printed by --help, [Parser.WriteMan] writes a section 1
+man page, and [Parser.WriteMarkdown] writes a Markdown reference.  All of
+them list every option with its value type, its default, and whether it
+may be repeated.
+
+[Parser.Group] puts options in titled sections, shown in the order the groups
+were declared, after options not in a group.  An Advanced group is left
+out of --help but documented, and a Hidden group is left out of usage,
+documentation, and completion scripts, though its options still work.
+[Parser.Hide] does the same for one option.  [Parser.Deprecate] keeps an
+old name working after an option is renamed, without showing it, and
+warns that the new name should be used.
+
 [Getopt::Long]: https://perldoc.perl.org/Getopt::Long
 */
 package getopt
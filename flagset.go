@@ -0,0 +1,217 @@
+package getopt
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// isBoolValue reports whether v is a [flag.Value] for a boolean flag, which
+// takes no value.
+func isBoolValue(v any) bool {
+	b, ok := v.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// FromFlagSet returns descriptor and pointer pairs for every flag defined
+// in fs, for passing to [GetOptions] or [NewParser].  Each flag's
+// [flag.Value] is bound by name, and values are checked as for other
+// flag.Values.  Boolean flags are negatable, unless fs already defines a
+// name made with the default negation prefixes, such as "nocolor"
+// alongside "color".  Flags whose names can't be option names,
+// such as "log.level" or the "test.v" flags defined by the testing
+// package, are left out.
+//
+//	rest, err := GetOptions(args, FromFlagSet(fs)...)
+func FromFlagSet(fs *flag.FlagSet) []any {
+	var a []any
+	fs.VisitAll(func(f *flag.Flag) {
+		if !isName(f.Name) {
+			return
+		}
+		desc := f.Name
+		if isBoolValue(f.Value) && !definesAny(fs, prefixed(defaultNegationPrefixes, f.Name)) {
+			desc += "!"
+		}
+		a = append(a, desc, f.Value)
+	})
+	return a
+}
+
+// definesAny reports whether fs defines a flag with any of names.
+func definesAny(fs *flag.FlagSet, names []string) bool {
+	for _, name := range names {
+		if fs.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ToFlagSet defines a flag in fs for every option in the [Parser], for
+// libraries which require a [flag.FlagSet].  Values parsed by fs are stored
+// directly, and negatable options also define their negated names.  The
+// --help and --version options are not defined, because fs provides its
+// own help.  Defaults of options marked with [Parser.Sensitive] are not
+// shown by fs.
+func (p *Parser) ToFlagSet(fs *flag.FlagSet) error {
+	for _, spec := range p.oc.specs {
+		if spec.ptr == nil {
+			continue
+		}
+		for _, name := range p.oc.names(spec) {
+			if fs.Lookup(name) != nil {
+				return errors.New("flag " + name + " already defined")
+			}
+		}
+
+		switch f := spec.ptr.(type) {
+		case *bool:
+			fs.BoolVar(f, spec.name, *f, spec.help)
+			if spec.negatable {
+				p.defineNegated(fs, spec, f)
+			}
+		case *int:
+			if spec.counting {
+				fs.Var(countingValue{f}, spec.name, spec.help)
+			} else {
+				fs.IntVar(f, spec.name, *f, spec.help)
+			}
+		case *float64:
+			fs.Float64Var(f, spec.name, *f, spec.help)
+		case *string:
+			fs.StringVar(f, spec.name, *f, spec.help)
+		case *[]int:
+			fs.Var(arrayValue[int]{f, strconv.Atoi}, spec.name, spec.help)
+		case *[]float64:
+			parse := func(s string) (float64, error) {
+				return strconv.ParseFloat(s, 64)
+			}
+			fs.Var(arrayValue[float64]{f, parse}, spec.name, spec.help)
+		case *[]string:
+			parse := func(s string) (string, error) {
+				return s, nil
+			}
+			fs.Var(arrayValue[string]{f, parse}, spec.name, spec.help)
+		case flag.Value:
+			fs.Var(f, spec.name, spec.help)
+			if spec.negatable {
+				p.defineNegated(fs, spec, f)
+			}
+		}
+		if spec.sensitive {
+			// PrintDefaults leaves out defaults which look like the
+			// zero value.
+			f := fs.Lookup(spec.name)
+			f.DefValue = zeroString(f.Value)
+		}
+	}
+	return nil
+}
+
+// zeroString returns how the zero value of v's type is shown, as the flag
+// package works it out, or "" if that panics.
+func zeroString(v flag.Value) (s string) {
+	defer func() {
+		if recover() != nil {
+			s = ""
+		}
+	}()
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		return reflect.New(t.Elem()).Interface().(flag.Value).String()
+	}
+	return reflect.Zero(t).Interface().(flag.Value).String()
+}
+
+// defineNegated defines the affirmed and negated names of a negatable
+// option, whose option is a *bool or boolean flag.Value.
+func (p *Parser) defineNegated(fs *flag.FlagSet, spec *optionSpec, option any) {
+	for _, name := range p.oc.affirmedNames(spec.name) {
+		fs.Var(aliasBoolValue{option, false}, name, spec.help)
+	}
+	for _, name := range p.oc.negatedNames(spec.name) {
+		fs.Var(aliasBoolValue{option, true}, name, spec.help)
+	}
+}
+
+// aliasBoolValue is a boolean flag which stores its value in another, or
+// the opposite of its value if negated, for --noflag.
+type aliasBoolValue struct {
+	option  any // *bool or flag.Value
+	negated bool
+}
+
+func (v aliasBoolValue) IsBoolFlag() bool {
+	return true
+}
+
+func (v aliasBoolValue) String() string {
+	return ""
+}
+
+func (v aliasBoolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b = b != v.negated
+	switch f := v.option.(type) {
+	case *bool:
+		*f = b
+	case flag.Value:
+		return f.Set(strconv.FormatBool(b))
+	}
+	return nil
+}
+
+// countingValue is a boolean flag which increments an integer.
+type countingValue struct {
+	option *int
+}
+
+func (v countingValue) IsBoolFlag() bool {
+	return true
+}
+
+func (v countingValue) String() string {
+	if v.option == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.option)
+}
+
+func (v countingValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if b {
+		*v.option++
+	}
+	return nil
+}
+
+// arrayValue appends each value to a slice.
+type arrayValue[T any] struct {
+	option *[]T
+	parse  func(string) (T, error)
+}
+
+func (v arrayValue[_]) String() string {
+	if v.option == nil || len(*v.option) == 0 {
+		return ""
+	}
+	return fmt.Sprint(*v.option)
+}
+
+func (v arrayValue[_]) Set(s string) error {
+	t, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+	*v.option = append(*v.option, t)
+	return nil
+}
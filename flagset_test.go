@@ -0,0 +1,179 @@
+package getopt
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestFromFlagSet_Base(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", true, "")
+	length := fs.Int("length", 24, "")
+	name := fs.String("name", "hello", "")
+	args := []string{"--noverbose", "--length", "10", "--name=world", "rest"}
+
+	a, err := GetOptions(args, FromFlagSet(fs)...)
+
+	assert.NoError(t, err)
+	assert.False(t, *verbose)
+	assert.Equal(t, *length, 10)
+	assert.Equal(t, *name, "world")
+	assert.Equal(t, a, args[4:])
+}
+
+// Flags which can't be options are left out.
+func TestFromFlagSet_BadName(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.String("log.level", "info", "")
+	verbose := fs.Bool("verbose", false, "")
+
+	a, err := GetOptions([]string{"--verbose"}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.Empty(t, a)
+
+	_, err = GetOptions([]string{"--log.level=debug"}, FromFlagSet(fs)...)
+	assert.ErrorContains(t, err, "Arg log.level not recognized")
+}
+
+// Boolean flags whose negated names are flags of their own aren't
+// negatable.
+func TestFromFlagSet_NegatedNameDefined(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	color := fs.Bool("color", false, "")
+	nocolor := fs.Bool("nocolor", false, "")
+	verify := fs.Bool("verify", true, "")
+	noVerify := fs.Bool("no-verify", false, "")
+	fast := fs.Bool("fast", true, "")
+
+	p, err := NewParser(Config{}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--color", "--nocolor", "--no-verify", "--nofast"})
+
+	assert.NoError(t, err)
+	assert.True(t, *color)
+	assert.True(t, *nocolor)
+	assert.True(t, *verify)
+	assert.True(t, *noVerify)
+	assert.False(t, *fast)
+}
+
+// flag.CommandLine has the testing package's flags, such as -test.v.
+func TestFromFlagSet_CommandLine(t *testing.T) {
+	_, err := NewParser(Config{}, FromFlagSet(flag.CommandLine)...)
+
+	assert.NoError(t, err)
+}
+
+// Values are checked before anything is committed.
+func TestFromFlagSet_InvalidArg(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	name := fs.String("name", "hello", "")
+	fs.Int("length", 24, "")
+	args := []string{"--name=world", "--length", "not a number"}
+
+	a, err := GetOptions(args, FromFlagSet(fs)...)
+
+	assert.ErrorContains(t, err, `invalid value "not a number": parse error`)
+	assert.Equal(t, *name, "hello")
+	assert.Equal(t, a, args)
+}
+
+// Values aren't set until all options are processed.
+func TestFromFlagSet_Deferred(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	length := fs.Int("length", 24, "")
+	args := []string{"--length", "10", "--bogus"}
+
+	a, err := GetOptions(args, FromFlagSet(fs)...)
+
+	assert.ErrorContains(t, err, "not recognized")
+	assert.Equal(t, *length, 24)
+	assert.Equal(t, a, args)
+}
+
+// flag.Value works with an explicit descriptor.
+func TestFlagValue_Typed(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Int("length", 24, "")
+	args := []string{"--size=10"}
+
+	a, err := GetOptions(args, "size=s", fs.Lookup("length").Value)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fs.Lookup("length").Value.String(), "10")
+	assert.Empty(t, a)
+}
+
+// Boolean flag.Values must be bound as booleans.
+func TestFlagValue_TypeMismatch(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Bool("verbose", false, "")
+
+	_, err := GetOptions([]string{}, "verbose=s", fs.Lookup("verbose").Value)
+
+	assert.ErrorContains(t, err, "descriptor type mismatch")
+}
+
+func TestToFlagSet_Base(t *testing.T) {
+	verbose := false
+	count := 0
+	length := 24
+	scale := 1.0
+	name := "hello"
+	tags := []string{}
+	sizes := []int{}
+
+	p, err := NewParser(Config{},
+		"verbose!", &verbose, "count+", &count, "length", &length,
+		"scale", &scale, "name", &name, "tag@", &tags, "size@", &sizes)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Describe("length", "The length."))
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	err = p.ToFlagSet(fs)
+	assert.NoError(t, err)
+
+	err = fs.Parse([]string{
+		"-verbose", "-noverbose", "-count", "-count", "-length=10",
+		"-scale=2.5", "-name=world", "-tag=a", "-tag=b", "-size=3", "rest",
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, verbose)
+	assert.Equal(t, count, 2)
+	assert.Equal(t, length, 10)
+	assert.Equal(t, scale, 2.5)
+	assert.Equal(t, name, "world")
+	assert.Equal(t, tags, []string{"a", "b"})
+	assert.Equal(t, sizes, []int{3})
+	assert.Equal(t, fs.Args(), []string{"rest"})
+	assert.Equal(t, fs.Lookup("length").Usage, "The length.")
+}
+
+func TestToFlagSet_Conflict(t *testing.T) {
+	verbose := false
+
+	p, err := NewParser(Config{}, "verbose!", &verbose)
+	assert.NoError(t, err)
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Bool("noverbose", false, "")
+	err = p.ToFlagSet(fs)
+
+	assert.ErrorContains(t, err, "flag noverbose already defined")
+	assert.Nil(t, fs.Lookup("verbose"))
+}
+
+// The builtin help isn't exported, since FlagSet provides its own.
+func TestToFlagSet_NoBuiltins(t *testing.T) {
+	p, err := NewParser(Config{Help: true})
+	assert.NoError(t, err)
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	err = p.ToFlagSet(fs)
+
+	assert.NoError(t, err)
+	assert.Nil(t, fs.Lookup("help"))
+	assert.ErrorIs(t, fs.Parse([]string{"-help"}), flag.ErrHelp)
+}
@@ -2,65 +2,108 @@ package getopt
 
 import (
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-var descRe = func() func() (*regexp.Regexp, error) {
-	var re *regexp.Regexp
+// Descriptor holds the parts of a descriptor string, from
+// [ParseDescriptor].
+type Descriptor struct {
+	Name     string
+	Optional bool // ':' rather than '='
+	Type     rune // 'b', 'i', 'f', 's', or '?' if not given
+	Modifier byte // '!', '+', '@', or 0 if not given
+}
+
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
 
-	return func() (*regexp.Regexp, error) {
-		if re != nil {
-			return re, nil
+// isName reports whether s can be used as an option name.
+func isName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isNameByte(s[i]) {
+			return false
 		}
+	}
+	return s != ""
+}
+
+// descError reports what was wrong with desc, and where.
+func descError(desc string, offset int, problem string) error {
+	return fmt.Errorf("descriptor not understood: %s at offset %d in %q",
+		problem, offset, desc)
+}
+
+// unexpected describes the character at offset, for errors.
+func unexpected(desc string, offset int) string {
+	r, _ := utf8.DecodeRuneInString(desc[offset:])
+	return fmt.Sprintf("unexpected %q", r)
+}
 
-		// A descriptor is a flag name, an optional type, and
-		// optional modifiers.  Not all modifiers apply to all
-		// types.
-		re, err := regexp.Compile("^([-_a-zA-Z0-9]+)([=:][bifs])?([!+@])?$")
-		return re, err
+// ParseDescriptor splits a descriptor into a flag name, an optional type,
+// and an optional modifier, for tools which work with descriptors.  Not all
+// modifiers apply to all types, and what applies also depends on the
+// pointer passed with the descriptor, so ParseDescriptor doesn't check
+// that.  "<>" is not accepted.
+func ParseDescriptor(desc string) (Descriptor, error) {
+	d := Descriptor{Type: '?'}
+
+	i := 0
+	for i < len(desc) && isNameByte(desc[i]) {
+		i++
+	}
+	if i == 0 && len(desc) == 0 {
+		return d, descError(desc, i, "missing name")
+	} else if i == 0 {
+		return d, descError(desc, i, "missing name, "+unexpected(desc, i))
 	}
-}()
+	d.Name = desc[:i]
 
-func parseOption(oc *optionCollection, desc string, ptr any) error {
-	// Parse the descriptor for requested flag attributes.
-	negatable := false
-	counting := false
-	optional := false
-	dArray := false
-	dType := '?'
-
-	re, err := descRe()
-	if err != nil {
-		return err
+	if i < len(desc) && (desc[i] == '=' || desc[i] == ':') {
+		d.Optional = desc[i] == ':'
+		i++
+		if i == len(desc) || strings.IndexByte("bifs", desc[i]) < 0 {
+			return d, descError(desc, i, "expected type b, i, f, or s")
+		}
+		d.Type = rune(desc[i])
+		i++
 	}
 
-	match := re.FindStringSubmatch(desc)
-	if match == nil {
-		// TODO: More-specific error reporting?
-		return errors.New("descriptor not understood")
+	if i < len(desc) && strings.IndexByte("!+@", desc[i]) >= 0 {
+		d.Modifier = desc[i]
+		i++
 	}
-	// desc, eType, modifier := match[1], match[2], match[3]
-	// Can't get type directly, though, since it would be a string.
 
-	if len(match[3]) > 0 {
-		if match[3][0] == '!' {
-			negatable = true
-		} else if match[3][0] == '+' {
-			counting = true
-		} else if match[3][0] == '@' {
-			dArray = true
-		} else {
-			return errors.New("descriptor not understood")
-		}
+	if i < len(desc) {
+		return d, descError(desc, i, unexpected(desc, i))
+	}
+	return d, nil
+}
+
+func parseOption(oc *optionCollection, desc string, ptr any) error {
+	if desc == argumentDescriptor {
+		return parseArguments(oc, ptr)
 	}
 
-	if len(match[2]) > 0 {
-		optional = (match[2][0] == ':')
-		dType = rune(match[2][1])
+	d, err := ParseDescriptor(desc)
+	if err != nil {
+		return err
 	}
-	name := match[1]
+
+	// Requested flag attributes.
+	negatable := d.Modifier == '!'
+	counting := d.Modifier == '+'
+	dArray := d.Modifier == '@'
+	optional := d.Optional
+	dType := d.Type
+	name := d.Name
 
 	// Probe the argument for type information.
 	pType := '?'
@@ -84,6 +127,11 @@ func parseOption(oc *optionCollection, desc string, ptr any) error {
 	case *[]string:
 		pType = 's'
 		pArray = true
+	case flag.Value:
+		pType = 's'
+		if isBoolValue(ptr) {
+			pType = 'b'
+		}
 	default:
 		return errors.New("type not recognized")
 	}
@@ -152,10 +200,48 @@ func parseOption(oc *optionCollection, desc string, ptr any) error {
 		}
 	case *[]string:
 		oc.addStringArrayHandler(name, (*[]string)(f))
+	case flag.Value:
+		if pType == 'b' && negatable {
+			oc.addNegatableValueHandler(name, f)
+		} else if pType == 'b' {
+			oc.addBoolValueHandler(name, f)
+		} else if optional {
+			oc.addOptionalValueHandler(name, f)
+		} else {
+			oc.addValueHandler(name, f)
+		}
 	default:
 		return errors.New("type not recognized")
 	}
 
+	h, _ := oc.lookup(name)
+	oc.addSpec(&optionSpec{
+		name:      name,
+		kind:      pType,
+		argType:   h.getType(),
+		negatable: negatable,
+		counting:  counting,
+		array:     pArray,
+		ptr:       ptr,
+		def:       formatDefault(ptr),
+	})
+
+	return nil
+}
+
+// argumentDescriptor takes non-option arguments, as in Getopt::Long.
+const argumentDescriptor = "<>"
+
+// parseArguments sets up the callback for non-option arguments.
+func parseArguments(oc *optionCollection, ptr any) error {
+	f, ok := ptr.(func(string) error)
+	if !ok {
+		return errors.New("descriptor type mismatch")
+	}
+	if oc.arguments != nil {
+		return errors.New("option already exists")
+	}
+	oc.arguments = f
 	return nil
 }
 
@@ -186,15 +272,123 @@ func parseOptions(oc *optionCollection, a ...any) error {
 	return nil
 }
 
-func processArgs(oc *optionCollection, args []string) ([]string, error) {
+// trimPrefix checks whether arg looks like an option, and if so returns it
+// with the option prefix removed.  "--" returns an empty name.
+func (oc *optionCollection) trimPrefix(arg string) (string, bool) {
+	if strings.HasPrefix(arg, "--") {
+		return arg[2:], true
+	}
+	if oc.config.Help && arg == "-h" {
+		return "help", true
+	}
+	// A lone "-" conventionally means stdin, and a negative number such
+	// as "-5" is a value, so neither is an option.
+	if oc.config.SingleDash && len(arg) > 1 && arg[0] == '-' && !isNumber('f', arg) {
+		return arg[1:], true
+	}
+	return arg, false
+}
+
+// isNumber reports whether arg is a value for an option of the given kind,
+// so "-5" can be the value of an int option even if it looks like an
+// option.  Only numbers starting with a digit count, so names like "-inf"
+// are still options.
+func isNumber(kind rune, arg string) bool {
+	digits := strings.TrimLeft(arg, "+-")
+	if len(arg)-len(digits) > 1 || digits == "" {
+		return false
+	} else if !('0' <= digits[0] && digits[0] <= '9') && digits[0] != '.' {
+		return false
+	}
+
+	var err error
+	switch kind {
+	case 'i':
+		_, err = strconv.Atoi(arg)
+	case 'f':
+		_, err = strconv.ParseFloat(arg, 64)
+	default:
+		return false
+	}
+	return err == nil
+}
+
+// handleValue adds the changes for one occurrence of an option, at pos in
+// the arguments, to plan, following the option's policies.
+func (oc *optionCollection) handleValue(plan *Plan, spec *optionSpec, h optionHandler, pos int, zeroOrOne []string) error {
+	if policy := oc.repeatPolicy(spec); policy != LastWins {
+		if first, ok := plan.firstPosition(spec); ok && policy == FirstWins {
+			return nil
+		} else if ok {
+			return fmt.Errorf("option %s given more than once, at arguments %d and %d",
+				spec.name, first, pos)
+		}
+	}
+
+	if spec.indirect && len(zeroOrOne) > 0 {
+		value, from, err := oc.resolve(plan, spec, zeroOrOne[0])
+		if err != nil {
+			return err
+		}
+		err = handleResolved(plan, spec, h, []string{value})
+		if err != nil && spec.sensitive {
+			err = redact(err)
+		}
+		if err != nil && from != "" {
+			return fmt.Errorf("option %s: value from %s: %w", spec.name, from, err)
+		}
+		return err
+	}
+
+	err := handleResolved(plan, spec, h, zeroOrOne)
+	if err != nil && spec.sensitive {
+		err = redact(err)
+	}
+	return err
+}
+
+// handleResolved adds the changes for zeroOrOne, after any indirection.
+func handleResolved(plan *Plan, spec *optionSpec, h optionHandler, zeroOrOne []string) error {
+	switch {
+	case spec.arrayPolicy == ReplaceDefaults && !plan.given(spec):
+		plan.add(spec, newClearCommitter(spec.ptr))
+	case spec.arrayPolicy == ClearOnEmpty && len(zeroOrOne) > 0 && zeroOrOne[0] == "":
+		plan.add(spec, newClearCommitter(spec.ptr))
+		return nil
+	}
+
+	if spec.split != nil && len(zeroOrOne) > 0 {
+		return spec.split.handle(plan, spec, h, zeroOrOne[0])
+	}
+	c, err := h.handle(zeroOrOne)
+	if err != nil {
+		return err
+	}
+	plan.add(spec, c)
+	return nil
+}
+
+// processArgs works out what the arguments ask for, but doesn't change
+// anything until the returned Plan is applied.
+func processArgs(oc *optionCollection, args []string) (*Plan, error) {
 	rest := args
+	plan := newPlan(oc, len(args))
+
+	// Set when a sensitive option took no value, so the next argument
+	// might have been meant as its value.
+	redactNext := false
 
 	for len(rest) > 0 {
-		name := rest[0]
-		if !strings.HasPrefix(name, "--") {
+		pos := len(args) - len(rest)
+		name, ok := oc.trimPrefix(rest[0])
+		if !ok && oc.arguments != nil {
+			// Options may follow non-option arguments.
+			plan.add(nil, argumentCommitter{rest[0], oc.arguments})
+			rest = rest[1:]
+			continue
+		} else if !ok {
 			break
 		}
-		name = name[2:]
 		rest = rest[1:]
 		if len(name) == 0 {
 			break
@@ -208,9 +402,15 @@ func processArgs(oc *optionCollection, args []string) ([]string, error) {
 			name = name[:i]
 		}
 
-		h, ok := oc.handlers[name]
-		if !ok {
-			return args, errors.New("Arg " + name + " not recognized")
+		h, ok := oc.lookup(name)
+		if !ok && redactNext {
+			return nil, errors.New("Arg " + redacted + " not recognized")
+		} else if !ok {
+			return nil, errors.New("Arg " + name + " not recognized")
+		}
+		spec, _ := oc.lookupSpec(name)
+		if replacement, ok := oc.deprecated[oc.key(name)]; ok {
+			plan.warn(fmt.Sprintf("option --%s is deprecated, use --%s", name, replacement))
 		}
 
 		if h.getType() == optionNoArg {
@@ -219,51 +419,231 @@ func processArgs(oc *optionCollection, args []string) ([]string, error) {
 			// Nothing, already have an arg
 		} else if len(rest) < 1 {
 			if h.getType() == optionRequiredArg {
-				return args, errors.New("missing required argument")
+				return nil, errors.New("missing required argument")
 			}
 			// For optional, no more args is fine
-		} else if h.getType() == optionOptionalArg && strings.HasPrefix(rest[0], "--") {
+		} else if isNumber(spec.kind, rest[0]) {
+			// Numbers are values, even if they look flag-like.
+			zeroOrOne = rest[0:1]
+			rest = rest[1:]
+		} else if _, ok := oc.trimPrefix(rest[0]); ok && h.getType() == optionOptionalArg {
 			// Nothing, next arg looks flag-like
 		} else {
 			zeroOrOne = rest[0:1]
 			rest = rest[1:]
 		}
 
-		c, err := h.handle(zeroOrOne)
-		if err != nil {
-			return args, err
+		if err := oc.handleValue(plan, spec, h, pos, zeroOrOne); err != nil {
+			return nil, err
+		}
+		plan.Result.record(spec, pos, zeroOrOne)
+		if oc.repeatPolicy(spec) != LastWins || spec.arrayPolicy == ReplaceDefaults {
+			plan.noteFirst(spec, pos)
+		}
+		redactNext = spec.sensitive && len(zeroOrOne) == 0
+	}
+	if oc.arguments != nil {
+		for _, arg := range rest {
+			plan.add(nil, argumentCommitter{arg, oc.arguments})
 		}
-		oc.committers = append(oc.committers, c)
+		rest = rest[len(rest):]
+	}
+	rest, err := oc.processPositionals(plan, rest)
+	if err != nil {
+		return nil, err
+	}
+	plan.Result.Args = rest
+	return plan, nil
+}
+
+// Config holds settings which change how a [Parser] processes options.
+// The zero value gives the behavior of [GetOptions].
+type Config struct {
+	// IgnoreCase matches option names without regard to case, so
+	// --Verbose and --VERBOSE both match a "verbose" descriptor.  Names
+	// which differ only in case conflict with each other.
+	IgnoreCase bool
+
+	// SingleDash also accepts long options with a single dash, as in
+	// -name, -name=value, or -noname.  There are no short options, so a
+	// single-dash argument always names a long option, and long names
+	// will take precedence over any future short option bundles.  "--"
+	// still ends option processing, and "-" and negative numbers such
+	// as "-5" or "-1.5" are not options.
+	SingleDash bool
+
+	// Output receives anything the parser prints, such as help or
+	// answers to completion requests.  If nil, [os.Stdout] is used.
+	Output io.Writer
+
+	// Warnings receives warnings, such as for deprecated options, one
+	// per line.  If nil, [os.Stderr] is used.
+	Warnings io.Writer
+
+	// Stdin is read for values given as "-" to options using
+	// [Parser.Indirect].  If nil, [os.Stdin] is used.
+	Stdin io.Reader
+
+	// Help adds --help and -h options, which print usage to Output and
+	// stop processing with [ErrHelp].  With SingleDash, "h" is a name of
+	// the help option like any other, so a descriptor named "h"
+	// conflicts with it.
+	Help bool
+
+	// Version, if set, adds a --version option, which prints Name and
+	// Version to Output and stops processing with [ErrVersion].
+	Version string
+
+	// Name is the program name shown in usage.  If empty, the base name
+	// of [os.Args][0] is used.
+	Name string
+
+	// NegationPrefixes are added to the names of negatable options to
+	// make names which set them to false.  If nil, "no" and "no-" are
+	// used, so --nocolor and --no-color both work.  The first prefix is
+	// the one shown in usage.
+	NegationPrefixes []string
+
+	// AffirmationPrefixes are added to the names of negatable options to
+	// make more names which set them to true, such as "enable-" to pair
+	// with a "disable-" negation prefix.
+	AffirmationPrefixes []string
+
+	// RepeatPolicy says what happens when an option which holds one
+	// value is given more than once.  The default is [LastWins].  It
+	// applies to [flag.Value] options holding a single bool, number, or
+	// string, such as those from the flag package, but not to other
+	// flag.Values, which may collect values, unless set for them with
+	// [Parser.SetRepeatPolicy].
+	RepeatPolicy RepeatPolicy
+}
+
+// Parser holds a processed set of option descriptors and pointers, which
+// can be applied to a slice of arguments.  Descriptors are parsed once, by
+// [NewParser], so a Parser can be kept and reused for many argument slices.
+// Once configured, a Parser is not changed by parsing, and may be shared
+// between goroutines.  [Parser.Plan] does not change any variables, so
+// goroutines can each plan their own arguments and read the [Result].
+// [Parser.Parse] and [Plan.Apply] write the variables the Parser was made
+// with, so calls to them must not overlap.
+type Parser struct {
+	oc *optionCollection
+}
+
+// NewParser processes the argument descriptors and pointers using the
+// settings in config.  See [GetOptions] for details.
+func NewParser(config Config, a ...any) (*Parser, error) {
+	p := &Parser{newOptionCollection(config)}
+	p.addBuiltins()
+
+	err := parseOptions(p.oc, a...)
+	if err != nil {
+		return nil, err
 	}
-	oc.commit()
-	return rest, nil
+
+	return p, nil
+}
+
+// spec finds the option with the given name, for methods which change its
+// settings.
+func (p *Parser) spec(name string) (*optionSpec, error) {
+	spec, ok := p.oc.lookupSpec(name)
+	if !ok {
+		return nil, errors.New("Arg " + name + " not recognized")
+	}
+	return spec, nil
+}
+
+// GetOptions processes options from the passed slice of arguments.
+// Returns the remaining arguments in case of success, or the original
+// arguments in case of error.
+func (p *Parser) GetOptions(args []string) ([]string, error) {
+	r, err := p.Parse(args)
+	if err != nil {
+		return args, err
+	}
+	return r.Args, nil
+}
+
+// Parse processes options from the passed slice of arguments like
+// [Parser.GetOptions], but returns a [Result] which also reports how each
+// option was given.
+func (p *Parser) Parse(args []string) (*Result, error) {
+	if len(args) > 0 && args[0] == completeOption && p.hasCompleters() {
+		return nil, p.complete(args[1:])
+	}
+	plan, err := processArgs(p.oc, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.Apply(); err != nil {
+		return nil, err
+	}
+	return plan.Result, nil
+}
+
+// Plan processes options from the passed slice of arguments, but only
+// returns the changes they ask for, without changing any variables.  Call
+// [Plan.Apply] to make the changes.
+//
+// Some options still act while planning.  --help and --version print to
+// [Config.Output] and stop with [ErrHelp] or [ErrVersion], and a completion
+// request is answered, as for [Parser.Parse].  Values read by
+// [Parser.Indirect] are read while planning, so "-" consumes [Config.Stdin].
+func (p *Parser) Plan(args []string) (*Plan, error) {
+	if len(args) > 0 && args[0] == completeOption && p.hasCompleters() {
+		return nil, p.complete(args[1:])
+	}
+	plan, err := processArgs(p.oc, args)
+	if err != nil {
+		return nil, err
+	}
+	plan.describe()
+	return plan, nil
 }
 
 // Process the argument descriptors and pointers from the passed slice of
 // arguments.  Returns the remaining arguments in case of success, or the
 // original arguments in case of error.
 func GetOptions(args []string, a ...any) ([]string, error) {
-	oc := newOptionCollection()
-
-	err := parseOptions(oc, a...)
+	p, err := NewParser(Config{}, a...)
 	if err != nil {
 		return args, err
 	}
 
-	return processArgs(oc, args)
+	return p.GetOptions(args)
+}
+
+// osArgs returns the arguments after the program name in [os.Args].
+func osArgs() []string {
+	if len(os.Args) == 0 {
+		return nil
+	}
+	return os.Args[1:]
+}
+
+// GetOSArgs wraps [GetOptions] to read options from [os.Args][1:].  Returns
+// the remaining arguments in case of success.  [os.Args] is not changed.
+func GetOSArgs(a ...any) ([]string, error) {
+	return GetOptions(osArgs(), a...)
 }
 
 // GetOSOptions wraps [GetOptions] to read options from [os.Args][1:],
-// destructively updating [os.Args][1:] in case of success.
+// destructively updating [os.Args][1:] in case of success.  [os.Args] is
+// global, so this should only be called once, before any other goroutines
+// are started.  Use [GetOSArgs] to get the remaining arguments without
+// changing [os.Args].
 func GetOSOptions(a ...any) error {
-	ret, err := GetOptions(os.Args[1:], a...)
+	ret, err := GetOSArgs(a...)
 	if err != nil {
 		return err
 	}
+	if len(os.Args) == 0 {
+		return nil
+	}
 
-	replace := []string{os.Args[1]}
-	os.Args = append(replace, ret...)
-	return err
+	os.Args = append([]string{os.Args[0]}, ret...)
+	return nil
 }
 
 // Perl's GetOptions() takes descriptors:
@@ -302,3 +682,5 @@ func GetOSOptions(a ...any) error {
 // TODO: Implement alternate names.
 // TODO: Allow short options as alternates.
 // TODO: Allow short option batching.
+// TODO: Map groups to sections of config files, and to prefixes of
+// environment variables, once options can be read from either.
@@ -1,9 +1,12 @@
 package getopt
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"log"
+	"os"
 	"testing"
 )
 
@@ -694,6 +697,579 @@ func TestString_TypeMismatch(t *testing.T) {
 	assert.Equal(t, a, args)
 }
 
+func TestIgnoreCase_Base(t *testing.T) {
+	args := []string{"--Flag", "--VALUE=5", "not a flag"}
+	flag := false
+	value := 3
+
+	p, err := NewParser(Config{IgnoreCase: true}, "flag", &flag, "value", &value)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, value, 5)
+	assert.Equal(t, a, args[2:])
+}
+
+func TestIgnoreCase_Negatable(t *testing.T) {
+	args := []string{"--NoFlag", "not a flag"}
+	flag := true
+
+	p, err := NewParser(Config{IgnoreCase: true}, "Flag!", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.False(t, flag)
+	assert.Equal(t, a, args[1:])
+}
+
+// Without IgnoreCase, case matters.
+func TestIgnoreCase_Off(t *testing.T) {
+	args := []string{"--Flag", "not a flag"}
+	flag := false
+
+	a, err := GetOptions(args, "flag", &flag)
+
+	assert.ErrorContains(t, err, "not recognized")
+	assert.False(t, flag)
+	assert.Equal(t, a, args)
+}
+
+// Names which differ only by case conflict.
+func TestIgnoreCase_Conflict(t *testing.T) {
+	flag := false
+	other := false
+
+	p, err := NewParser(Config{IgnoreCase: true},
+		"verbose", &flag, "Verbose", &other)
+
+	assert.ErrorContains(t, err, "option already exists")
+	assert.Nil(t, p)
+}
+
+// Negated names conflict without regard to case.
+func TestIgnoreCase_NegatedConflict(t *testing.T) {
+	flag := false
+	other := false
+
+	_, err := NewParser(Config{IgnoreCase: true},
+		"NoFlag", &other, "flag!", &flag)
+
+	assert.ErrorContains(t, err, "option already exists")
+}
+
+func TestSingleDash_Base(t *testing.T) {
+	args := []string{"-flag", "-value", "5", "-name=world", "not a flag"}
+	flag := false
+	value := 3
+	name := "hello"
+
+	p, err := NewParser(Config{SingleDash: true},
+		"flag", &flag, "value", &value, "name", &name)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, value, 5)
+	assert.Equal(t, name, "world")
+	assert.Equal(t, a, args[4:])
+}
+
+func TestSingleDash_Negatable(t *testing.T) {
+	args := []string{"-noflag", "not a flag"}
+	flag := true
+
+	p, err := NewParser(Config{SingleDash: true}, "flag!", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.False(t, flag)
+	assert.Equal(t, a, args[1:])
+}
+
+// Double-dash options still work, and "--" still ends processing.
+func TestSingleDash_DoubleDash(t *testing.T) {
+	args := []string{"--flag", "--", "-notaflag"}
+	flag := false
+
+	p, err := NewParser(Config{SingleDash: true}, "flag", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, a, args[2:])
+}
+
+// A lone "-" is not an option.
+func TestSingleDash_Stdin(t *testing.T) {
+	args := []string{"-flag", "-", "not a flag"}
+	flag := false
+
+	p, err := NewParser(Config{SingleDash: true}, "flag", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, a, args[1:])
+}
+
+// Negative numbers are not options, so they end option processing, or go
+// to positional parameters or "<>".
+func TestSingleDash_Number(t *testing.T) {
+	flag := false
+	n := 0
+	var seen []string
+
+	p, err := NewParser(Config{SingleDash: true}, "flag", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions([]string{"-flag", "-5", "-1.5", "-flag"})
+	assert.NoError(t, err)
+	assert.Equal(t, a, []string{"-5", "-1.5", "-flag"})
+
+	p, err = NewParser(Config{SingleDash: true}, "flag", &flag)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Positional("n=i", &n))
+	_, err = p.GetOptions([]string{"-5"})
+	assert.NoError(t, err)
+	assert.Equal(t, n, -5)
+
+	p, err = NewParser(Config{SingleDash: true}, "flag", &flag,
+		"<>", func(arg string) error {
+			seen = append(seen, arg)
+			return nil
+		})
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"-3", "-flag", "-.5"})
+	assert.NoError(t, err)
+	assert.Equal(t, seen, []string{"-3", "-.5"})
+}
+
+// Optional values are not taken from a single-dash option.
+func TestSingleDash_OptionalWithFlag(t *testing.T) {
+	args := []string{"-value", "-flag", "not a flag"}
+	value := "something"
+	flag := false
+
+	p, err := NewParser(Config{SingleDash: true},
+		"value:s", &value, "flag", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, value, "")
+	assert.True(t, flag)
+	assert.Equal(t, a, args[2:])
+}
+
+// Without SingleDash, single-dash arguments end option processing.
+func TestSingleDash_Off(t *testing.T) {
+	args := []string{"-flag", "not a flag"}
+	flag := false
+
+	a, err := GetOptions(args, "flag", &flag)
+
+	assert.NoError(t, err)
+	assert.False(t, flag)
+	assert.Equal(t, a, args)
+}
+
+// A Parser can be used more than once.
+func TestParser_Reuse(t *testing.T) {
+	count := 0
+
+	p, err := NewParser(Config{}, "count+", &count)
+	assert.NoError(t, err)
+
+	_, err = p.GetOptions([]string{"--count"})
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--count"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, count, 2)
+}
+
+// Goroutines can share a Parser, each planning its own arguments.
+func TestParser_Parallel(t *testing.T) {
+	length := 0
+	tags := []string{}
+
+	p, err := NewParser(Config{}, "length=i", &length, "tag=s@", &tags)
+	assert.NoError(t, err)
+
+	for i := range 8 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			args := []string{"--length", fmt.Sprint(i), "--tag", "a", fmt.Sprint(i)}
+
+			plan, err := p.Plan(args)
+
+			assert.NoError(t, err)
+			assert.Equal(t, plan.Result.Args, []string{fmt.Sprint(i)})
+			or, _ := plan.Result.Option("length")
+			assert.Equal(t, or.Values, []string{fmt.Sprint(i)})
+			assert.Equal(t, plan.Assignments[0].Value, i)
+		})
+	}
+	t.Cleanup(func() {
+		assert.Equal(t, length, 0)
+		assert.Empty(t, tags)
+	})
+}
+
+// Parsers with their own variables can parse in parallel.
+func TestParser_ParallelParse(t *testing.T) {
+	for i := range 8 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			length := 0
+			args := []string{"--length", fmt.Sprint(i), "rest"}
+
+			rest, err := GetOptions(args, "length=i", &length)
+
+			assert.NoError(t, err)
+			assert.Equal(t, rest, []string{"rest"})
+			assert.Equal(t, length, i)
+		})
+	}
+}
+
+func TestGetOSArgs_Base(t *testing.T) {
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = []string{"prog", "--length", "10", "rest"}
+	length := 0
+
+	rest, err := GetOSArgs("length=i", &length)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rest, []string{"rest"})
+	assert.Equal(t, length, 10)
+	assert.Equal(t, os.Args, []string{"prog", "--length", "10", "rest"})
+}
+
+func TestGetOSOptions_Base(t *testing.T) {
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = []string{"prog", "--length", "10", "rest"}
+	length := 0
+
+	err := GetOSOptions("length=i", &length)
+
+	assert.NoError(t, err)
+	assert.Equal(t, os.Args, []string{"prog", "rest"})
+	assert.Equal(t, length, 10)
+}
+
+func TestGetOSOptions_Error(t *testing.T) {
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = []string{"prog", "--length", "x", "rest"}
+	length := 0
+
+	err := GetOSOptions("length=i", &length)
+
+	assert.Error(t, err)
+	assert.Equal(t, os.Args, []string{"prog", "--length", "x", "rest"})
+}
+
+func TestGetOSOptions_NoArgs(t *testing.T) {
+	saved := os.Args
+	t.Cleanup(func() { os.Args = saved })
+	os.Args = nil
+	length := 0
+
+	err := GetOSOptions("length=i", &length)
+
+	assert.NoError(t, err)
+	assert.Empty(t, os.Args)
+}
+
+func TestArguments_Base(t *testing.T) {
+	args := []string{"--mode", "a", "file1", "--mode", "b", "file2", "--", "--file3"}
+	mode := ""
+	var seen []string
+	callback := func(arg string) error {
+		seen = append(seen, mode+":"+arg)
+		return nil
+	}
+
+	rest, err := GetOptions(args, "mode=s", &mode, "<>", callback)
+
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, seen, []string{"a:file1", "b:file2", "b:--file3"})
+}
+
+func TestArguments_Error(t *testing.T) {
+	args := []string{"file1", "--count", "file2", "file3"}
+	count := 0
+	var seen []string
+	callback := func(arg string) error {
+		if arg == "file2" {
+			return fmt.Errorf("bad file %s", arg)
+		}
+		seen = append(seen, arg)
+		return nil
+	}
+
+	_, err := GetOptions(args, "count+", &count, "<>", callback)
+
+	assert.ErrorContains(t, err, "bad file file2")
+	assert.Equal(t, seen, []string{"file1"})
+	assert.Equal(t, count, 1)
+}
+
+// Nothing is called if an option is bad.
+func TestArguments_BadOption(t *testing.T) {
+	args := []string{"file1", "--other"}
+	var seen []string
+	callback := func(arg string) error {
+		seen = append(seen, arg)
+		return nil
+	}
+
+	_, err := GetOptions(args, "<>", callback)
+
+	assert.ErrorContains(t, err, "Arg other not recognized")
+	assert.Empty(t, seen)
+}
+
+func TestArguments_Plan(t *testing.T) {
+	args := []string{"--count", "file1"}
+	count := 0
+	callback := func(arg string) error { return nil }
+
+	p, err := NewParser(Config{}, "count+", &count, "<>", callback)
+	assert.NoError(t, err)
+	plan, err := p.Plan(args)
+
+	assert.NoError(t, err)
+	assert.Len(t, plan.Assignments, 2)
+	assert.Equal(t, plan.Assignments[1].Option, "<>")
+	assert.Equal(t, plan.Assignments[1].Value, "file1")
+	assert.Equal(t, plan.Assignments[1].Op, OpCall)
+	assert.Equal(t, plan.Assignments[1].String(), "call <> file1")
+}
+
+func TestArguments_Mismatch(t *testing.T) {
+	callback := func(arg string) error { return nil }
+	var name string
+
+	_, err1 := GetOptions([]string{}, "<>", &name)
+	_, err2 := GetOptions([]string{}, "<>", callback, "<>", callback)
+
+	assert.ErrorContains(t, err1, "descriptor type mismatch")
+	assert.ErrorContains(t, err2, "option already exists")
+}
+
+func TestNegative_Required(t *testing.T) {
+	args := []string{"-offset", "-5", "-scale", "-1.5e3", "rest"}
+	offset := 0
+	scale := 1.0
+
+	p, err := NewParser(Config{SingleDash: true}, "offset=i", &offset, "scale=f", &scale)
+	assert.NoError(t, err)
+	rest, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rest, args[4:])
+	assert.Equal(t, offset, -5)
+	assert.Equal(t, scale, -1.5e3)
+}
+
+func TestNegative_Optional(t *testing.T) {
+	args := []string{"-offset", "-5", "-scale", "-.5", "rest"}
+	offset := 0
+	scale := 1.0
+
+	p, err := NewParser(Config{SingleDash: true}, "offset:i", &offset, "scale:f", &scale)
+	assert.NoError(t, err)
+	rest, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rest, args[4:])
+	assert.Equal(t, offset, -5)
+	assert.Equal(t, scale, -.5)
+}
+
+// Negative numbers are never option names, so optional options take them
+// as values even if they don't fit, but names like "-inf" are options.
+func TestNegative_NotNumber(t *testing.T) {
+	offset := 3
+	name := "x"
+	inf := false
+
+	p, err := NewParser(Config{SingleDash: true},
+		"offset:i", &offset, "name:s", &name, "inf", &inf)
+	assert.NoError(t, err)
+
+	_, err = p.GetOptions([]string{"-offset", "-1.5"})
+	assert.ErrorContains(t, err, `parsing "-1.5": invalid syntax`)
+	_, err = p.GetOptions([]string{"-name", "-5"})
+	assert.NoError(t, err)
+	assert.Equal(t, name, "-5")
+	_, err = p.GetOptions([]string{"-offset", "-inf"})
+	assert.NoError(t, err)
+	assert.Equal(t, offset, 0)
+	assert.True(t, inf)
+}
+
+func TestIsNumber(t *testing.T) {
+	assert.True(t, isNumber('i', "-5"))
+	assert.True(t, isNumber('i', "+5"))
+	assert.True(t, isNumber('f', "-1.5e3"))
+	assert.True(t, isNumber('f', "-.5"))
+	assert.False(t, isNumber('i', "-1.5"))
+	assert.False(t, isNumber('f', "-inf"))
+	assert.False(t, isNumber('f', "--5"))
+	assert.False(t, isNumber('f', "-"))
+	assert.False(t, isNumber('s', "-5"))
+}
+
+func TestNegation_Hyphen(t *testing.T) {
+	args := []string{"--color", "--no-color", "rest"}
+	color := false
+
+	rest, err := GetOptions(args, "color!", &color)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rest, args[2:])
+	assert.False(t, color)
+}
+
+func TestNegation_Prefixes(t *testing.T) {
+	color := false
+	cache := true
+	config := Config{
+		NegationPrefixes:    []string{"disable-", "without-"},
+		AffirmationPrefixes: []string{"enable-", "with-"},
+	}
+
+	p, err := NewParser(config, "color!", &color, "cache!", &cache)
+	assert.NoError(t, err)
+
+	_, err = p.GetOptions([]string{"--enable-color", "--without-cache"})
+	assert.NoError(t, err)
+	assert.True(t, color)
+	assert.False(t, cache)
+
+	_, err = p.GetOptions([]string{"--disable-color", "--with-cache"})
+	assert.NoError(t, err)
+	assert.False(t, color)
+	assert.True(t, cache)
+
+	_, err = p.GetOptions([]string{"--nocolor"})
+	assert.ErrorContains(t, err, "Arg nocolor not recognized")
+}
+
+// Every generated name conflicts with other options.
+func TestNegation_Conflict(t *testing.T) {
+	color := false
+	other := false
+	config := Config{
+		NegationPrefixes:    []string{"disable-"},
+		AffirmationPrefixes: []string{"enable-"},
+	}
+
+	_, err1 := GetOptions([]string{}, "color!", &color, "no-color", &other)
+	_, err2 := GetOptions([]string{}, "no-color", &other, "color!", &color)
+	p, err3 := NewParser(config, "color!", &color, "enable-color", &other)
+	_, err4 := NewParser(Config{NegationPrefixes: []string{"no", "no"}}, "color!", &color)
+
+	assert.ErrorContains(t, err1, "option already exists")
+	assert.ErrorContains(t, err2, "option already exists")
+	assert.ErrorContains(t, err3, "option already exists")
+	assert.Nil(t, p)
+	assert.ErrorContains(t, err4, "option already exists")
+}
+
+func TestBoolValue_Base(t *testing.T) {
+	flag := false
+
+	for value, want := range map[string]bool{
+		"true": true, "yes": true, "on": true, "1": true, "TRUE": true,
+		"false": false, "no": false, "off": false, "0": false, "False": false,
+	} {
+		flag = !want
+		_, err := GetOptions([]string{"--flag=" + value}, "flag", &flag)
+
+		assert.NoError(t, err)
+		assert.Equal(t, flag, want, value)
+	}
+}
+
+func TestBoolValue_Negated(t *testing.T) {
+	flag := true
+
+	_, err := GetOptions([]string{"--noflag=false"}, "flag!", &flag)
+	assert.NoError(t, err)
+	assert.True(t, flag)
+
+	_, err = GetOptions([]string{"--no-flag=true"}, "flag!", &flag)
+	assert.NoError(t, err)
+	assert.False(t, flag)
+}
+
+// The value must be attached, so the next argument is not taken.
+func TestBoolValue_Separate(t *testing.T) {
+	args := []string{"--flag", "false"}
+	flag := false
+
+	rest, err := GetOptions(args, "flag", &flag)
+
+	assert.NoError(t, err)
+	assert.True(t, flag)
+	assert.Equal(t, rest, args[1:])
+}
+
+func TestBoolValue_Invalid(t *testing.T) {
+	flag := false
+
+	_, err := GetOptions([]string{"--flag=maybe"}, "flag", &flag)
+
+	assert.ErrorContains(t, err, `invalid boolean value "maybe"`)
+	assert.False(t, flag)
+}
+
+func TestBoolValue_FlagValue(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", true, "")
+
+	_, err := GetOptions([]string{"--verbose=off"}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	assert.False(t, *verbose)
+
+	_, err = GetOptions([]string{"--noverbose=no"}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+}
+
+func TestNoValue_Counting(t *testing.T) {
+	count := 0
+
+	_, err := GetOptions([]string{"--count=3"}, "count+", &count)
+
+	assert.ErrorContains(t, err, "option takes no value")
+	assert.Equal(t, count, 0)
+}
+
+func TestNoValue_Help(t *testing.T) {
+	var b bytes.Buffer
+
+	p, err := NewParser(Config{Help: true, Output: &b})
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--help=yes"})
+
+	assert.ErrorContains(t, err, "option takes no value")
+	assert.Empty(t, b.String())
+}
+
 func ExampleGetOptions() {
 	args := []string{
 		"--files=hello.world", "--length", "10", "--verbose", "rest",
@@ -715,3 +1291,108 @@ func ExampleGetOptions() {
 	// Output:
 	// length:10, data:hello.world, verbose:true, rest:[rest]
 }
+
+func TestDescriptor_Errors(t *testing.T) {
+	value := 0
+
+	for desc, msg := range map[string]string{
+		"":         `missing name at offset 0 in ""`,
+		"=i":       `missing name, unexpected '=' at offset 0 in "=i"`,
+		"value=":   `expected type b, i, f, or s at offset 6 in "value="`,
+		"value=q":  `expected type b, i, f, or s at offset 6 in "value=q"`,
+		"value!!":  `unexpected '!' at offset 6 in "value!!"`,
+		"valué":    `unexpected 'é' at offset 4 in "valué"`,
+		"value=i?": `unexpected '?' at offset 7 in "value=i?"`,
+	} {
+		_, err := GetOptions([]string{}, desc, &value)
+
+		assert.ErrorContains(t, err, "descriptor not understood: "+msg)
+	}
+}
+
+func TestDescriptor_Parse(t *testing.T) {
+	for desc, want := range map[string]Descriptor{
+		"verbose":  {Name: "verbose", Type: '?'},
+		"flag!":    {Name: "flag", Type: '?', Modifier: '!'},
+		"level:i":  {Name: "level", Optional: true, Type: 'i'},
+		"file=s@":  {Name: "file", Type: 's', Modifier: '@'},
+		"v-name+":  {Name: "v-name", Type: '?', Modifier: '+'},
+		"scale=f":  {Name: "scale", Type: 'f'},
+		"2fast=b!": {Name: "2fast", Type: 'b', Modifier: '!'},
+	} {
+		d, err := ParseDescriptor(desc)
+
+		assert.NoError(t, err)
+		assert.Equal(t, d, want)
+	}
+
+	_, err := ParseDescriptor("<>")
+	assert.ErrorContains(t, err, "descriptor not understood")
+}
+
+// benchOptions returns descriptors for n integer options, and arguments
+// which set each of them.
+func benchOptions(n int) ([]any, []string) {
+	values := make([]int, n)
+	var a []any
+	var args []string
+	for i := range values {
+		name := fmt.Sprintf("option%d", i)
+		a = append(a, name+"=i", &values[i])
+		args = append(args, "--"+name, "5")
+	}
+	return a, append(args, "rest")
+}
+
+func BenchmarkNewParser(b *testing.B) {
+	for _, n := range []int{1, 50, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			a, _ := benchOptions(n)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewParser(Config{}, a...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetOptions(b *testing.B) {
+	for _, n := range []int{1, 50, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			a, args := benchOptions(n)
+			p, err := NewParser(Config{}, a...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.GetOptions(args); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Policies which look at earlier occurrences shouldn't slow parsing down.
+func BenchmarkGetOptions_FirstWins(b *testing.B) {
+	for _, n := range []int{1, 50, 500} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			a, args := benchOptions(n)
+			p, err := NewParser(Config{RepeatPolicy: FirstWins}, a...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.GetOptions(args); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Package getoptcheck defines an Analyzer which checks the descriptors
+// passed to [getopt.GetOptions], [getopt.GetOSArgs], [getopt.GetOSOptions],
+// [getopt.NewParser] and [getopt.Parser.Positional].
+//
+// Descriptor mistakes are otherwise only reported when the program runs.
+// The analyzer finds calls with literal descriptors, and checks them with
+// the same rules the getopt package uses, against the static types of the
+// pointers passed with them.  That catches descriptors which can't be
+// parsed, descriptors which don't match their pointer, an odd number of
+// arguments, and names which conflict, such as "flag!" with "noflag".
+//
+// Calls which pass a slice with "..." are not checked, and checking stops
+// at the first descriptor which is not a constant.  Descriptors passed to
+// NewParser are checked with the constant IgnoreCase, Help and Version
+// settings of a [getopt.Config] literal.  Names are not checked for
+// conflicts unless the Config is a literal which leaves the negation and
+// affirmation prefixes alone.
+package getoptcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"github.com/dshess/getopt"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const getoptPath = "github.com/dshess/getopt"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "getoptcheck",
+	Doc:      "check descriptors passed to getopt.GetOptions, getopt.GetOSArgs, getopt.GetOSOptions, getopt.NewParser and Parser.Positional",
+	URL:      "https://pkg.go.dev/github.com/dshess/getopt/getoptcheck",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	filter := []ast.Node{(*ast.CallExpr)(nil)}
+	inspect.Preorder(filter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != getoptPath {
+			return
+		}
+		if fn.Type().(*types.Signature).Recv() != nil {
+			if fn.Name() == "Positional" {
+				checkCall(pass, call, call.Args, checkPositional, true)
+			}
+			return
+		}
+
+		switch fn.Name() {
+		case "GetOptions":
+			// The first argument is the slice of arguments.
+			checkCall(pass, call, call.Args[1:], checkOptions(getopt.Config{}), true)
+		case "GetOSArgs", "GetOSOptions":
+			checkCall(pass, call, call.Args, checkOptions(getopt.Config{}), true)
+		case "NewParser":
+			// The first argument is the Config.
+			config := literalConfig(pass, call.Args[0])
+			checkCall(pass, call, call.Args[1:], checkOptions(config), !setsPrefixes(call.Args[0]))
+		}
+	})
+	return nil, nil
+}
+
+// checkOptions returns a function which checks pairs as options, with the
+// settings in config.
+func checkOptions(config getopt.Config) func(...any) error {
+	return func(pairs ...any) error {
+		_, err := getopt.NewParser(config, pairs...)
+		return err
+	}
+}
+
+// literalConfig returns the settings in config, an expression for a
+// getopt.Config, which change which names are taken: IgnoreCase, Help and
+// Version, if config is a literal which sets them to constants.
+func literalConfig(pass *analysis.Pass, config ast.Expr) getopt.Config {
+	var c getopt.Config
+	lit, ok := ast.Unparen(config).(*ast.CompositeLit)
+	if !ok {
+		return c
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		value := pass.TypesInfo.Types[kv.Value].Value
+		if !ok || value == nil {
+			continue
+		}
+		switch {
+		case key.Name == "IgnoreCase" && value.Kind() == constant.Bool:
+			c.IgnoreCase = constant.BoolVal(value)
+		case key.Name == "Help" && value.Kind() == constant.Bool:
+			c.Help = constant.BoolVal(value)
+		case key.Name == "Version" && value.Kind() == constant.String:
+			c.Version = constant.StringVal(value)
+		}
+	}
+	return c
+}
+
+// checkPositional checks pairs as positional parameters.
+func checkPositional(pairs ...any) error {
+	p, err := getopt.NewParser(getopt.Config{})
+	if err != nil {
+		return err
+	}
+	return p.Positional(pairs...)
+}
+
+// setsPrefixes reports whether config, an expression for a getopt.Config,
+// might set prefixes which change which names conflict.
+func setsPrefixes(config ast.Expr) bool {
+	lit, ok := ast.Unparen(config).(*ast.CompositeLit)
+	if !ok {
+		return true
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok || key.Name == "NegationPrefixes" || key.Name == "AffirmationPrefixes" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCall checks descriptor and pointer pairs in args with check.  If
+// together is set, each pair is checked along with the pairs before it,
+// which finds names which conflict.
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, args []ast.Expr, check func(...any) error, together bool) {
+	if call.Ellipsis.IsValid() {
+		return
+	}
+	if len(args)%2 == 1 {
+		pass.Reportf(call.Pos(), "getopt: odd number of arguments")
+		return
+	}
+
+	// Pairs which passed, so that later pairs are checked against them.
+	var valid []any
+	for ; len(args) >= 2; args = args[2:] {
+		// Named string types are not accepted.
+		tv := pass.TypesInfo.Types[args[0]]
+		if !types.Identical(types.Default(tv.Type), types.Typ[types.String]) {
+			pass.Reportf(args[0].Pos(), "getopt: descriptor must be string")
+			continue
+		}
+		if tv.Value == nil || tv.Value.Kind() != constant.String {
+			// Later names could conflict with this one.
+			return
+		}
+		desc := constant.StringVal(tv.Value)
+
+		t := pass.TypesInfo.TypeOf(args[1])
+		ptr, ok := samplePointer(t)
+		if !ok && types.IsInterface(t) {
+			// The dynamic type could be anything.
+			return
+		} else if !ok {
+			pass.Reportf(args[1].Pos(), "getopt: descriptor %q: type not recognized", desc)
+			continue
+		}
+
+		pair := append(valid[:len(valid):len(valid)], desc, ptr)
+		if err := check(pair...); err != nil {
+			pass.Reportf(args[0].Pos(), "getopt: descriptor %q: %v", desc, err)
+			continue
+		}
+		if together {
+			valid = pair
+		}
+	}
+}
+
+// sampleValue stands in for a flag.Value.
+type sampleValue struct {
+	isBool bool
+}
+
+func (v sampleValue) String() string   { return "" }
+func (v sampleValue) Set(string) error { return nil }
+func (v sampleValue) IsBoolFlag() bool { return v.isBool }
+
+// sampleArguments returns a function which getopt will accept for "<>" if
+// sig is func(string) error.
+func sampleArguments(sig *types.Signature) any {
+	params, results := sig.Params(), sig.Results()
+	if params.Len() == 1 && types.Identical(params.At(0).Type(), types.Typ[types.String]) &&
+		results.Len() == 1 && types.Identical(results.At(0).Type(), types.Universe.Lookup("error").Type()) &&
+		!sig.Variadic() {
+		return func(string) error { return nil }
+	}
+	return func() {}
+}
+
+// samplePointer returns a pointer of the same kind as t, which getopt will
+// treat the same way as one of type t.
+func samplePointer(t types.Type) (any, bool) {
+	if p, ok := types.Unalias(t).(*types.Pointer); ok {
+		switch elem := types.Unalias(p.Elem()).(type) {
+		case *types.Basic:
+			switch elem.Kind() {
+			case types.Bool:
+				return new(bool), true
+			case types.Int:
+				return new(int), true
+			case types.Float64:
+				return new(float64), true
+			case types.String:
+				return new(string), true
+			}
+		case *types.Slice:
+			if b, ok := types.Unalias(elem.Elem()).(*types.Basic); ok {
+				switch b.Kind() {
+				case types.Int:
+					return new([]int), true
+				case types.Float64:
+					return new([]float64), true
+				case types.String:
+					return new([]string), true
+				}
+			}
+		}
+	}
+
+	// A "<>" callback.
+	if sig, ok := t.Underlying().(*types.Signature); ok {
+		return sampleArguments(sig), true
+	}
+
+	// Anything else has to be a flag.Value.
+	ms := types.NewMethodSet(t)
+	if ms.Lookup(nil, "String") == nil || ms.Lookup(nil, "Set") == nil {
+		return nil, false
+	}
+	return sampleValue{ms.Lookup(nil, "IsBoolFlag") != nil}, true
+}
@@ -0,0 +1,12 @@
+package getoptcheck_test
+
+import (
+	"testing"
+
+	"github.com/dshess/getopt/getoptcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), getoptcheck.Analyzer, "a")
+}
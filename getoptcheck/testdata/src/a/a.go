@@ -0,0 +1,69 @@
+package a
+
+import (
+	"flag"
+
+	"github.com/dshess/getopt"
+)
+
+type level int
+
+type desc string
+
+func good(args []string, fs *flag.FlagSet) {
+	var verbose bool
+	var length int
+	var name string
+	var tags []string
+
+	getopt.GetOptions(args, "verbose!", &verbose, "length=i", &length)
+	getopt.GetOSOptions("name", &name, "tag=s@", &tags)
+	getopt.GetOSArgs("name", &name)
+	getopt.GetOptions(args, "name", &name, "<>", func(arg string) error { return nil })
+	getopt.GetOptions(args, "value", fs.Lookup("value").Value)
+
+	p, _ := getopt.NewParser(getopt.Config{Help: true}, "verbose!", &verbose, "length=i", &length)
+	p.Positional("name", &name, "tags=s@", &tags)
+
+	// These prefixes make "noverbose" free.
+	getopt.NewParser(getopt.Config{NegationPrefixes: []string{"un"}}, "verbose!", &verbose, "noverbose", &length)
+}
+
+func bad(args []string, v any) {
+	var verbose bool
+	var other bool
+	var length int
+	var name string
+	var lvl level
+
+	getopt.GetOptions(args, "verbose", &verbose, "length") // want `getopt: odd number of arguments`
+	getopt.GetOptions(args, "length=s", &length)           // want `getopt: descriptor "length=s": descriptor type mismatch`
+	getopt.GetOptions(args, "name=q", &name)               // want `getopt: descriptor "name=q": descriptor not understood`
+	getopt.GetOptions(args, "level", &lvl)                 // want `getopt: descriptor "level": type not recognized`
+	getopt.GetOptions(args, desc("name"), &name)           // want `getopt: descriptor must be string`
+	getopt.GetOSArgs("length=s", &length)                  // want `getopt: descriptor "length=s": descriptor type mismatch`
+	getopt.GetOptions(args, "<>", func(arg string) {})     // want `getopt: descriptor "<>": descriptor type mismatch`
+	getopt.GetOSOptions(
+		"verbose!", &verbose,
+		"noverbose", &other, // want `getopt: descriptor "noverbose": option already exists`
+		"name", &name,
+		"name", &name, // want `getopt: descriptor "name": option already exists`
+	)
+
+	getopt.NewParser(getopt.Config{}, "length=s", &length) // want `getopt: descriptor "length=s": descriptor type mismatch`
+	getopt.NewParser(getopt.Config{IgnoreCase: true},
+		"name", &name,
+		"name", &name, // want `getopt: descriptor "name": option already exists`
+	)
+	getopt.NewParser(getopt.Config{Help: true}, "help", &verbose)                   // want `getopt: descriptor "help": option already exists`
+	getopt.NewParser(getopt.Config{Version: "1.0"}, "version", &verbose)            // want `getopt: descriptor "version": option already exists`
+	getopt.NewParser(getopt.Config{IgnoreCase: true}, "Name", &name, "name", &name) // want `getopt: descriptor "name": option already exists`
+	p, _ := getopt.NewParser(getopt.Config{})
+	p.Positional("verbose", &verbose)                                        // want `getopt: descriptor "verbose": descriptor type mismatch`
+	p.Positional("name?", &name, "length", &length)                          // want `getopt: descriptor "length": required parameter after optional parameter`
+	p.Positional("name", &name, "<>", func(arg string) error { return nil }) // want `getopt: descriptor "<>": positional parameters can't be used with "<>"`
+
+	// Values which can't be known statically aren't checked.
+	getopt.GetOptions(args, "name", v, "name", &name)
+	getopt.GetOptions(args, []any{"name", &name, "name", &name}...)
+}
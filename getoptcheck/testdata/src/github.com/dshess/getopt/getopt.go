@@ -0,0 +1,33 @@
+// Package getopt is a stub of the real package, with the signatures the
+// analyzer looks for.
+package getopt
+
+func GetOptions(args []string, a ...any) ([]string, error) {
+	return args, nil
+}
+
+func GetOSArgs(a ...any) ([]string, error) {
+	return nil, nil
+}
+
+func GetOSOptions(a ...any) error {
+	return nil
+}
+
+type Config struct {
+	IgnoreCase          bool
+	Help                bool
+	Version             string
+	NegationPrefixes    []string
+	AffirmationPrefixes []string
+}
+
+type Parser struct{}
+
+func NewParser(config Config, a ...any) (*Parser, error) {
+	return &Parser{}, nil
+}
+
+func (p *Parser) Positional(a ...any) error {
+	return nil
+}
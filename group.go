@@ -0,0 +1,124 @@
+package getopt
+
+import (
+	"errors"
+	"slices"
+)
+
+// Visibility says where the options in a group are shown.
+type Visibility int
+
+const (
+	// Visible options are shown everywhere.
+	Visible Visibility = iota
+
+	// Advanced options are left out of the usage printed by --help, but
+	// are in man pages and Markdown references.
+	Advanced
+
+	// Hidden options are not shown in usage, documentation, or
+	// completion scripts.  They still work.
+	Hidden
+)
+
+// optionGroup is a section of related options, from [Parser.Group].
+type optionGroup struct {
+	title      string
+	visibility Visibility
+	specs      []*optionSpec
+}
+
+// section is a titled list of options to render.  Options which are not in
+// a group have an empty title.
+type section struct {
+	title string
+	specs []*optionSpec
+}
+
+// Group puts the named options in a group with the given title, so that
+// usage and documentation show them in a section of their own.  Groups are
+// shown in the order they were first declared, after any options which
+// are not in a group.  Calling Group again with the same title adds more
+// options, and changes the group's visibility.  An option can only be in
+// one group.  If any name can't be added, nothing is changed.
+func (p *Parser) Group(title string, visibility Visibility, names ...string) error {
+	oc := p.oc
+	if title == "" {
+		return errors.New("group title is empty")
+	}
+
+	// Check every name before changing anything.
+	specs := make([]*optionSpec, 0, len(names))
+	for _, name := range names {
+		spec, err := p.spec(name)
+		if err != nil {
+			return err
+		}
+		if spec.group != nil {
+			return errors.New("option " + spec.name + " already in group " + spec.group.title)
+		} else if slices.Contains(specs, spec) {
+			return errors.New("option " + spec.name + " given twice")
+		}
+		specs = append(specs, spec)
+	}
+
+	var g *optionGroup
+	for _, og := range oc.groups {
+		if og.title == title {
+			g = og
+		}
+	}
+	if g == nil {
+		g = &optionGroup{title: title}
+		oc.groups = append(oc.groups, g)
+	}
+	g.visibility = visibility
+
+	for _, spec := range specs {
+		spec.group = g
+		g.specs = append(g.specs, spec)
+	}
+	return nil
+}
+
+// visibility returns where spec is shown.
+func (spec *optionSpec) visibility() Visibility {
+	if spec.hidden {
+		return Hidden
+	} else if spec.group == nil {
+		return Visible
+	}
+	return spec.group.visibility
+}
+
+// sections returns the options to render, ungrouped options first, leaving
+// out options less visible than max.  Empty sections are left out.
+func (oc *optionCollection) sections(max Visibility) []section {
+	visible := func(specs []*optionSpec) []*optionSpec {
+		var shown []*optionSpec
+		for _, spec := range specs {
+			if spec.visibility() <= max {
+				shown = append(shown, spec)
+			}
+		}
+		return shown
+	}
+
+	var ungrouped []*optionSpec
+	for _, spec := range oc.specs {
+		if spec.group == nil {
+			ungrouped = append(ungrouped, spec)
+		}
+	}
+
+	var sections []section
+	if specs := visible(ungrouped); len(specs) > 0 {
+		sections = append(sections, section{"", specs})
+	}
+	for _, g := range oc.groups {
+		if specs := visible(g.specs); len(specs) > 0 {
+			sections = append(sections, section{g.title, specs})
+		}
+	}
+	return sections
+}
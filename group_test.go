@@ -0,0 +1,136 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// groupParser returns a parser with ungrouped, visible, advanced, and
+// hidden options.
+func groupParser(t *testing.T) *Parser {
+	var verbose, trace, secret bool
+	var host string
+	var port int
+
+	p, err := NewParser(Config{Name: "prog", Help: true},
+		"verbose", &verbose, "host=s", &host, "port=i", &port,
+		"trace", &trace, "secret", &secret)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Group("Network", Visible, "host", "port"))
+	assert.NoError(t, p.Group("Debug", Advanced, "trace"))
+	assert.NoError(t, p.Group("Internal", Hidden, "secret"))
+	return p
+}
+
+func TestGroup_Usage(t *testing.T) {
+	var b bytes.Buffer
+	p := groupParser(t)
+
+	err := p.WriteUsage(&b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, b.String(), `Usage: prog [options] [--] [args...]
+
+Options:
+  -h, --help            Show this help and exit.
+  --verbose
+
+Network:
+  --host=string
+  --port=int
+`)
+}
+
+// Advanced groups are documented, but hidden ones are not.
+func TestGroup_Docs(t *testing.T) {
+	var man, md bytes.Buffer
+	p := groupParser(t)
+
+	assert.NoError(t, p.WriteMan(&man, "prog", "Do things."))
+	assert.NoError(t, p.WriteMarkdown(&md, "prog", ""))
+
+	assert.Contains(t, man.String(), ".SS Network\n.TP\n.BI \\-\\-host =string\n")
+	assert.Contains(t, man.String(), ".SS Debug\n.TP\n.B \\-\\-trace\n")
+	assert.NotContains(t, man.String(), "secret")
+	assert.Contains(t, md.String(), "\n### Debug\n\n- `--trace`\n")
+	assert.NotContains(t, md.String(), "Internal")
+	assert.NotContains(t, md.String(), "secret")
+}
+
+func TestGroup_Completion(t *testing.T) {
+	var b bytes.Buffer
+	p := groupParser(t)
+
+	err := p.WriteCompletion(&b, "bash", "prog")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "--trace")
+	assert.NotContains(t, b.String(), "secret")
+}
+
+// Hidden options still work.
+func TestGroup_HiddenWorks(t *testing.T) {
+	secret := false
+
+	p, err := NewParser(Config{}, "secret", &secret)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Group("Internal", Hidden, "secret"))
+	_, err = p.GetOptions([]string{"--secret"})
+
+	assert.NoError(t, err)
+	assert.True(t, secret)
+}
+
+// Groups keep the order they were first declared in.
+func TestGroup_Order(t *testing.T) {
+	var a, b, c bool
+
+	p, err := NewParser(Config{}, "a", &a, "b", &b, "c", &c)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Group("Second", Visible, "b"))
+	assert.NoError(t, p.Group("First", Visible, "c"))
+	assert.NoError(t, p.Group("Second", Visible, "a"))
+	sections := p.oc.sections(Hidden)
+
+	assert.Len(t, sections, 2)
+	assert.Equal(t, sections[0].title, "Second")
+	assert.Equal(t, sections[0].specs, []*optionSpec{p.oc.specs[1], p.oc.specs[0]})
+	assert.Equal(t, sections[1].title, "First")
+}
+
+func TestGroup_Errors(t *testing.T) {
+	verbose := false
+
+	p, err := NewParser(Config{}, "verbose!", &verbose)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Group("", Visible), "group title is empty")
+	assert.ErrorContains(t, p.Group("Output", Visible, "other"), "Arg other not recognized")
+	assert.NoError(t, p.Group("Output", Visible, "verbose"))
+	assert.ErrorContains(t, p.Group("Debug", Visible, "noverbose"),
+		"option verbose already in group Output")
+	assert.ErrorContains(t, p.Group("Debug", Visible, "verbose", "noverbose"),
+		"option verbose already in group Output")
+}
+
+// Nothing changes if any name is bad.
+func TestGroup_ErrorsChangeNothing(t *testing.T) {
+	var b bytes.Buffer
+	verbose := false
+	debug := false
+
+	p, err := NewParser(Config{Name: "prog"}, "verbose!", &verbose, "debug", &debug)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Group("G", Hidden, "verbose", "other"), "Arg other not recognized")
+	assert.ErrorContains(t, p.Group("G", Hidden, "debug", "debug"), "option debug given twice")
+	assert.NoError(t, p.WriteUsage(&b))
+	assert.Equal(t, b.String(), `Usage: prog [options] [--] [args...]
+
+Options:
+  --[no]verbose
+  --debug
+`)
+	assert.Empty(t, p.oc.groups)
+}
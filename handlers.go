@@ -2,7 +2,11 @@ package getopt
 
 import (
 	"errors"
+	"flag"
+	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 )
 
 // TODO: Right now, this is structured as a map of handler objects, which
@@ -12,7 +16,11 @@ import (
 
 // optionCommitters allow deferring changes until the end of options processing.
 type optionCommitter interface {
-	commit()
+	commit() error
+
+	// assignment describes the change for a Plan, without the option
+	// name.
+	assignment() Assignment
 }
 
 // Store a value at a pointer on commit.
@@ -21,8 +29,13 @@ type optionSimpleCommitter[T any] struct {
 	option *T
 }
 
-func (o optionSimpleCommitter[_]) commit() {
+func (o optionSimpleCommitter[_]) commit() error {
 	*o.option = o.value
+	return nil
+}
+
+func (o optionSimpleCommitter[_]) assignment() Assignment {
+	return Assignment{Target: o.option, Value: o.value, Op: OpSet}
 }
 
 // Increment a pointed-to value on commit.
@@ -30,8 +43,13 @@ type optionCountingCommitter struct {
 	option *int
 }
 
-func (o optionCountingCommitter) commit() {
+func (o optionCountingCommitter) commit() error {
 	*o.option++
+	return nil
+}
+
+func (o optionCountingCommitter) assignment() Assignment {
+	return Assignment{Target: o.option, Op: OpIncrement}
 }
 
 // Append value to an array on commit.
@@ -40,8 +58,102 @@ type optionArrayCommitter[T any] struct {
 	option *[]T
 }
 
-func (o optionArrayCommitter[_]) commit() {
+func (o optionArrayCommitter[_]) commit() error {
 	*o.option = append(*o.option, o.value)
+	return nil
+}
+
+func (o optionArrayCommitter[_]) assignment() Assignment {
+	return Assignment{Target: o.option, Value: o.value, Op: OpAppend}
+}
+
+// Empty a slice on commit.
+type optionClearCommitter[T any] struct {
+	option *[]T
+}
+
+func (o optionClearCommitter[_]) commit() error {
+	*o.option = nil
+	return nil
+}
+
+func (o optionClearCommitter[_]) assignment() Assignment {
+	return Assignment{Target: o.option, Op: OpClear}
+}
+
+// newClearCommitter returns a committer which empties the slice ptr points
+// to.
+func newClearCommitter(ptr any) optionCommitter {
+	switch f := ptr.(type) {
+	case *[]int:
+		return optionClearCommitter[int]{f}
+	case *[]float64:
+		return optionClearCommitter[float64]{f}
+	case *[]string:
+		return optionClearCommitter[string]{f}
+	}
+	return nil
+}
+
+// Call a function with a non-option argument on commit.
+type argumentCommitter struct {
+	arg      string
+	callback func(string) error
+}
+
+func (o argumentCommitter) commit() error {
+	return o.callback(o.arg)
+}
+
+func (o argumentCommitter) assignment() Assignment {
+	return Assignment{Option: argumentDescriptor, Target: o.callback, Value: o.arg, Op: OpCall}
+}
+
+// Pass a value to a flag.Value on commit.  This can fail, because Set may
+// reject a value which couldn't be checked earlier.
+type optionValueCommitter struct {
+	value  string
+	option flag.Value
+}
+
+func (o optionValueCommitter) commit() error {
+	if err := o.option.Set(o.value); err != nil {
+		return &valueError{o.value, err}
+	}
+	return nil
+}
+
+func (o optionValueCommitter) assignment() Assignment {
+	return Assignment{Target: o.option, Value: o.value, Op: OpSet}
+}
+
+// valueError reports a value which a flag.Value rejected.
+type valueError struct {
+	value string
+	err   error
+}
+
+func (e *valueError) Error() string {
+	return fmt.Sprintf("invalid value %q: %v", e.value, e.err)
+}
+
+func (e *valueError) Unwrap() error {
+	return e.err
+}
+
+// errNoValue is returned for a value given to an option which takes none,
+// as in --count=3.
+var errNoValue = errors.New("option takes no value")
+
+// parseBool parses a value given to a boolean option, as in --flag=false.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value %q", s)
 }
 
 // optionHandler provides a hint as to how many arguments, and a handler to call
@@ -70,7 +182,16 @@ func (oh optionSimpleHandler) getType() optionType {
 	return oh.t
 }
 func (oh optionSimpleHandler) handle(args []string) (optionCommitter, error) {
-	c := optionSimpleCommitter[bool]{oh.value, oh.option}
+	value := oh.value
+	if len(args) > 0 {
+		b, err := parseBool(args[0])
+		if err != nil {
+			return nil, err
+		}
+		// --noflag=false sets true.
+		value = b == oh.value
+	}
+	c := optionSimpleCommitter[bool]{value, oh.option}
 	return c, nil
 }
 
@@ -83,6 +204,9 @@ func (oh optionCountingHandler) getType() optionType {
 	return oh.t
 }
 func (oh optionCountingHandler) handle(args []string) (optionCommitter, error) {
+	if len(args) > 0 {
+		return nil, errNoValue
+	}
 	c := optionCountingCommitter{oh.option}
 	return c, nil
 }
@@ -192,130 +316,398 @@ func (oh optionStringArrayHandler) handle(args []string) (optionCommitter, error
 	return c, nil
 }
 
+// optionValueHandler passes values to a flag.Value.  If there is no value,
+// such as for boolean values, it passes fixed instead.
+type optionValueHandler struct {
+	t      optionType
+	fixed  string
+	option flag.Value
+}
+
+func (oh optionValueHandler) getType() optionType {
+	return oh.t
+}
+func (oh optionValueHandler) handle(args []string) (optionCommitter, error) {
+	value := oh.fixed
+	if len(args) > 0 && oh.t == optionNoArg {
+		// A boolean flag.Value, where fixed is "true" or "false".
+		b, err := parseBool(args[0])
+		if err != nil {
+			return nil, err
+		}
+		value = strconv.FormatBool(b == (oh.fixed == "true"))
+	} else if len(args) > 0 {
+		value = args[0]
+	}
+	if err := checkValue(oh.option, value); err != nil {
+		return nil, err
+	}
+	c := optionValueCommitter{value, oh.option}
+	return c, nil
+}
+
+// checkValue tries value on a new variable of the type option points to, so
+// that bad values are found before anything is committed.  Only types
+// holding a single bool, number, or string, such as those from the flag
+// package, are tried.  Set on other types, such as maps or functions,
+// might change state shared with option, so is left until commit.
+func checkValue(option flag.Value, value string) error {
+	if !isScalarValue(option) {
+		return nil
+	}
+	t := reflect.TypeOf(option)
+	if err := reflect.New(t.Elem()).Interface().(flag.Value).Set(value); err != nil {
+		return &valueError{value, err}
+	}
+	return nil
+}
+
+// isScalarValue reports whether option points to a single bool, number, or
+// string, so holds one value rather than collecting them.
+func isScalarValue(option flag.Value) bool {
+	t := reflect.TypeOf(option)
+	if t.Kind() != reflect.Pointer {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	}
+	return false
+}
+
+// optionActionHandler runs an action as soon as the option is seen, such
+// as printing help.  Any error from the action stops processing.
+type optionActionHandler struct {
+	t      optionType
+	action func() error
+}
+
+func (oh optionActionHandler) getType() optionType {
+	return oh.t
+}
+func (oh optionActionHandler) handle(args []string) (optionCommitter, error) {
+	if len(args) > 0 {
+		return nil, errNoValue
+	}
+	return nil, oh.action()
+}
+
+// optionSpec records what a descriptor asked for, so that options can be
+// described to users, such as in completion scripts.
+type optionSpec struct {
+	// Position in the collection's specs.
+	index int
+
+	name      string
+	kind      rune // 'b', 'i', 'f', or 's'
+	argType   optionType
+	negatable bool
+	counting  bool
+	array     bool
+
+	// Where values are stored.
+	ptr any
+
+	// Single-dash alias, such as "h" for -h.
+	short string
+
+	// Value of the pointed-to variable when the option was described,
+	// or "" if it was the zero value.
+	def string
+
+	// Text explaining the option to users.
+	help string
+
+	// Generates candidate values for dynamic completion.
+	completer func(prefix string) []string
+
+	// The group the option is shown in, or nil.
+	group *optionGroup
+
+	// Left out of usage, documentation, and completion.
+	hidden bool
+
+	// Values may name a file, stdin, or environment variable to read.
+	indirect bool
+
+	// Values are secret, so are redacted when shown.
+	sensitive bool
+
+	// Splits each value into elements, for array options.
+	split *splitter
+
+	// How values given for an array option combine with what it held.
+	arrayPolicy ArrayPolicy
+
+	// What happens when a scalar option is repeated, if set for this
+	// option rather than by Config.
+	repeatPolicy    RepeatPolicy
+	hasRepeatPolicy bool
+}
+
 type optionCollection struct {
+	config Config
+
 	// <flag name> => <handler for that flag>
 	handlers map[string]optionHandler
 
-	// Defer updates until after all options are processed.
-	committers []optionCommitter
+	// Options in the order they were described.
+	specs []*optionSpec
+
+	// <flag name> => <spec which generated that flag>
+	byName map[string]*optionSpec
+
+	// <deprecated flag name> => <flag name to use instead>
+	deprecated map[string]string
+
+	// Called with each non-option argument, from the "<>" descriptor.
+	arguments func(string) error
+
+	// Parameters from Parser.Positional, and a collection holding their
+	// handlers, kept apart so they aren't options.
+	params  []positional
+	paramOC *optionCollection
+
+	// Groups in the order they were declared.
+	groups []*optionGroup
 }
 
-func newOptionCollection() *optionCollection {
+func newOptionCollection(config Config) *optionCollection {
 	return &optionCollection{
-		make(map[string]optionHandler),
-		make([]optionCommitter, 0, 10),
+		config:     config,
+		handlers:   make(map[string]optionHandler),
+		byName:     make(map[string]*optionSpec),
+		deprecated: make(map[string]string),
 	}
 }
 
+func (oc *optionCollection) addSpec(spec *optionSpec) {
+	spec.index = len(oc.specs)
+	oc.specs = append(oc.specs, spec)
+	for _, name := range oc.names(spec) {
+		oc.byName[oc.key(name)] = spec
+	}
+}
+
+// lookupSpec finds the spec for any of the names it accepts.
+func (oc *optionCollection) lookupSpec(name string) (*optionSpec, bool) {
+	spec, ok := oc.byName[oc.key(name)]
+	return spec, ok
+}
+
+// names returns every name which the option accepts.
+func (oc *optionCollection) names(spec *optionSpec) []string {
+	names := []string{spec.name}
+	if spec.negatable {
+		names = append(names, oc.affirmedNames(spec.name)...)
+		names = append(names, oc.negatedNames(spec.name)...)
+	}
+	return names
+}
+
+// key maps an option name to the name used in the handlers map.
+func (oc *optionCollection) key(name string) string {
+	if oc.config.IgnoreCase {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func (oc *optionCollection) lookup(name string) (optionHandler, bool) {
+	h, ok := oc.handlers[oc.key(name)]
+	return h, ok
+}
+
+func (oc *optionCollection) setHandler(name string, h optionHandler) {
+	oc.handlers[oc.key(name)] = h
+}
+
 func (oc *optionCollection) addSimpleHandler(name string, option *bool) {
-	oc.handlers[name] = optionSimpleHandler{
+	oc.setHandler(name, optionSimpleHandler{
 		optionNoArg,
 		true,
 		option,
+	})
+}
+
+// defaultNegationPrefixes is used when Config.NegationPrefixes is nil.
+var defaultNegationPrefixes = []string{"no", "no-"}
+
+func (oc *optionCollection) negationPrefixes() []string {
+	if oc.config.NegationPrefixes == nil {
+		return defaultNegationPrefixes
 	}
+	return oc.config.NegationPrefixes
 }
 
-func negatedName(name string) string {
-	return "no" + name
+// prefixed returns name with each of prefixes.
+func prefixed(prefixes []string, name string) []string {
+	names := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		names[i] = prefix + name
+	}
+	return names
+}
+
+// negatedNames returns the names which set a negatable option to false.
+func (oc *optionCollection) negatedNames(name string) []string {
+	return prefixed(oc.negationPrefixes(), name)
+}
+
+// affirmedNames returns the names, other than name itself, which set a
+// negatable option to true.
+func (oc *optionCollection) affirmedNames(name string) []string {
+	return prefixed(oc.config.AffirmationPrefixes, name)
 }
 
 func (oc *optionCollection) checkNameConflict(name string, negatable bool) error {
-	if _, ok := oc.handlers[name]; ok {
-		return errors.New("option already exists")
-	}
+	names := []string{name}
 	if negatable {
-		if _, ok := oc.handlers[negatedName(name)]; ok {
+		names = append(names, oc.affirmedNames(name)...)
+		names = append(names, oc.negatedNames(name)...)
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if _, ok := oc.lookup(name); ok || seen[oc.key(name)] {
 			return errors.New("option already exists")
 		}
+		seen[oc.key(name)] = true
 	}
 	return nil
 }
 
 func (oc *optionCollection) addNegatableHandler(name string, option *bool) {
-	oc.handlers[name] = optionSimpleHandler{
-		optionNoArg,
-		true,
-		option,
+	for _, name := range append([]string{name}, oc.affirmedNames(name)...) {
+		oc.setHandler(name, optionSimpleHandler{
+			optionNoArg,
+			true,
+			option,
+		})
+	}
+	for _, name := range oc.negatedNames(name) {
+		oc.setHandler(name, optionSimpleHandler{
+			optionNoArg,
+			false,
+			option,
+		})
 	}
-	oc.handlers[negatedName(name)] = optionSimpleHandler{
+}
+
+func (oc *optionCollection) addBoolValueHandler(name string, option flag.Value) {
+	oc.setHandler(name, optionValueHandler{
 		optionNoArg,
-		false,
+		"true",
 		option,
+	})
+}
+
+func (oc *optionCollection) addNegatableValueHandler(name string, option flag.Value) {
+	for _, name := range append([]string{name}, oc.affirmedNames(name)...) {
+		oc.addBoolValueHandler(name, option)
+	}
+	for _, name := range oc.negatedNames(name) {
+		oc.setHandler(name, optionValueHandler{
+			optionNoArg,
+			"false",
+			option,
+		})
 	}
 }
 
+func (oc *optionCollection) addValueHandler(name string, option flag.Value) {
+	oc.setHandler(name, optionValueHandler{
+		optionRequiredArg,
+		"",
+		option,
+	})
+}
+
+func (oc *optionCollection) addOptionalValueHandler(name string, option flag.Value) {
+	oc.setHandler(name, optionValueHandler{
+		optionOptionalArg,
+		"",
+		option,
+	})
+}
+
+func (oc *optionCollection) addActionHandler(name string, action func() error) {
+	oc.setHandler(name, optionActionHandler{
+		optionNoArg,
+		action,
+	})
+}
+
 func (oc *optionCollection) addCountingHandler(name string, option *int) {
-	oc.handlers[name] = optionCountingHandler{
+	oc.setHandler(name, optionCountingHandler{
 		optionNoArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addIntHandler(name string, option *int) {
-	oc.handlers[name] = optionIntHandler{
+	oc.setHandler(name, optionIntHandler{
 		optionRequiredArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addOptionalIntHandler(name string, option *int) {
-	oc.handlers[name] = optionIntHandler{
+	oc.setHandler(name, optionIntHandler{
 		optionOptionalArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addIntArrayHandler(name string, option *[]int) {
-	oc.handlers[name] = optionIntArrayHandler{
+	oc.setHandler(name, optionIntArrayHandler{
 		optionRequiredArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addFloatHandler(name string, option *float64) {
-	oc.handlers[name] = optionFloatHandler{
+	oc.setHandler(name, optionFloatHandler{
 		optionRequiredArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addOptionalFloatHandler(name string, option *float64) {
-	oc.handlers[name] = optionFloatHandler{
+	oc.setHandler(name, optionFloatHandler{
 		optionOptionalArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addFloatArrayHandler(name string, option *[]float64) {
-	oc.handlers[name] = optionFloatArrayHandler{
+	oc.setHandler(name, optionFloatArrayHandler{
 		optionRequiredArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addStringHandler(name string, option *string) {
-	oc.handlers[name] = optionStringHandler{
+	oc.setHandler(name, optionStringHandler{
 		optionRequiredArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addOptionalStringHandler(name string, option *string) {
-	oc.handlers[name] = optionStringHandler{
+	oc.setHandler(name, optionStringHandler{
 		optionOptionalArg,
 		option,
-	}
+	})
 }
 
 func (oc *optionCollection) addStringArrayHandler(name string, option *[]string) {
-	oc.handlers[name] = optionStringArrayHandler{
+	oc.setHandler(name, optionStringArrayHandler{
 		optionRequiredArg,
 		option,
-	}
-}
-
-func (oc *optionCollection) commit() {
-	for _, e := range oc.committers {
-		e.commit()
-	}
+	})
 }
@@ -0,0 +1,125 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrHelp is returned by [Parser.GetOptions] after --help or -h printed
+// usage.  No options are committed, and the program should exit
+// successfully.
+var ErrHelp = errors.New("help requested")
+
+// ErrVersion is returned by [Parser.GetOptions] after --version printed
+// the version.  No options are committed, and the program should exit
+// successfully.
+var ErrVersion = errors.New("version requested")
+
+// Usage text is indented by this much, and help text starts in this column
+// when the option fits.
+const (
+	usageIndent = 2
+	usageColumn = 24
+)
+
+func (p *Parser) output() io.Writer {
+	if p.oc.config.Output != nil {
+		return p.oc.config.Output
+	}
+	return os.Stdout
+}
+
+func (p *Parser) name() string {
+	if p.oc.config.Name != "" {
+		return p.oc.config.Name
+	}
+	if len(os.Args) > 0 {
+		return filepath.Base(os.Args[0])
+	}
+	return ""
+}
+
+// addBuiltins adds the options requested by the config, before any
+// descriptors so that descriptors which use the same names conflict.
+func (p *Parser) addBuiltins() {
+	oc := p.oc
+
+	if oc.config.Help {
+		help := func() error {
+			if err := p.WriteUsage(p.output()); err != nil {
+				return err
+			}
+			return ErrHelp
+		}
+		oc.addActionHandler("help", help)
+		spec := &optionSpec{
+			name:    "help",
+			kind:    'b',
+			argType: optionNoArg,
+			short:   "h",
+			help:    "Show this help and exit.",
+		}
+		oc.addSpec(spec)
+		if oc.config.SingleDash {
+			// -h names an option like any other single-dash argument,
+			// so "h" can't be taken by a descriptor.
+			oc.addActionHandler("h", help)
+			oc.byName[oc.key("h")] = spec
+		}
+	}
+
+	if oc.config.Version != "" {
+		oc.addActionHandler("version", func() error {
+			_, err := fmt.Fprintln(p.output(), p.name(), oc.config.Version)
+			if err != nil {
+				return err
+			}
+			return ErrVersion
+		})
+		oc.addSpec(&optionSpec{
+			name:    "version",
+			kind:    'b',
+			argType: optionNoArg,
+			help:    "Show the version and exit.",
+		})
+	}
+}
+
+// WriteUsage writes a usage message listing every option to w, as printed
+// by the --help option.
+func (p *Parser) WriteUsage(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage: %s %s\n", p.name(), p.synopsis())
+
+	for _, s := range p.oc.sections(Visible) {
+		title := s.title
+		if title == "" {
+			title = "Options"
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+
+		for _, spec := range s.specs {
+			name, value := p.oc.optionUsage(spec)
+			line := strings.Repeat(" ", usageIndent) + name + value
+			text := strings.TrimSpace(strings.Join(
+				append([]string{spec.help}, optionNotes(spec)...), " "))
+
+			if text == "" {
+				// Nothing to add.
+			} else if len(line) < usageColumn {
+				line += strings.Repeat(" ", usageColumn-len(line)) + text
+			} else {
+				line += "\n" + strings.Repeat(" ", usageColumn) + text
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
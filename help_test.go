@@ -0,0 +1,132 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHelp_Base(t *testing.T) {
+	var b bytes.Buffer
+	args := []string{"--value=5", "--help", "--bogus"}
+	value := 3
+
+	p, err := NewParser(Config{Help: true, Name: "prog", Output: &b},
+		"value", &value)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Describe("value", "The value."))
+	a, err := p.GetOptions(args)
+
+	assert.ErrorIs(t, err, ErrHelp)
+	assert.Equal(t, value, 3)
+	assert.Equal(t, a, args)
+	assert.Equal(t, b.String(), `Usage: prog [options] [--] [args...]
+
+Options:
+  -h, --help            Show this help and exit.
+  --value=int           The value. Default: 3.
+`)
+}
+
+func TestHelp_Short(t *testing.T) {
+	var b bytes.Buffer
+	args := []string{"-h"}
+
+	p, err := NewParser(Config{Help: true, Name: "prog", Output: &b})
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.ErrorIs(t, err, ErrHelp)
+	assert.Contains(t, b.String(), "Usage: prog [options]")
+	assert.Equal(t, a, args)
+}
+
+// Without Help, neither option is recognized.
+func TestHelp_Off(t *testing.T) {
+	var b bytes.Buffer
+	args := []string{"--help"}
+
+	p, err := NewParser(Config{Output: &b})
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.ErrorContains(t, err, "not recognized")
+	assert.Empty(t, b.String())
+	assert.Equal(t, a, args)
+
+	a, err = p.GetOptions([]string{"-h"})
+	assert.NoError(t, err)
+	assert.Equal(t, a, []string{"-h"})
+}
+
+func TestHelp_Conflict(t *testing.T) {
+	help := false
+
+	_, err := NewParser(Config{Help: true}, "help", &help)
+
+	assert.ErrorContains(t, err, "option already exists")
+}
+
+// With SingleDash, -h is a long option, so "h" is taken by help.
+func TestHelp_SingleDashConflict(t *testing.T) {
+	var b bytes.Buffer
+	h := false
+
+	_, err := NewParser(Config{Help: true, SingleDash: true}, "h", &h)
+	assert.ErrorContains(t, err, "option already exists")
+
+	p, err := NewParser(Config{Help: true, SingleDash: true, Output: &b})
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"-h"})
+	assert.ErrorIs(t, err, ErrHelp)
+	assert.Contains(t, b.String(), "Usage:")
+
+	// Without SingleDash, --h is not help, so "h" is free.
+	p, err = NewParser(Config{Help: true}, "h", &h)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--h"})
+	assert.NoError(t, err)
+	assert.True(t, h)
+}
+
+// Options too long for the column get help on the next line.
+func TestHelp_LongOption(t *testing.T) {
+	var b bytes.Buffer
+	value := ""
+
+	p, err := NewParser(Config{Name: "prog"}, "a-rather-long-option", &value)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Describe("a-rather-long-option", "Long."))
+	err = p.WriteUsage(&b)
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(),
+		"  --a-rather-long-option=string\n                        Long.\n")
+}
+
+func TestVersion_Base(t *testing.T) {
+	var b bytes.Buffer
+	args := []string{"--flag", "--version"}
+	flag := false
+
+	p, err := NewParser(Config{Version: "1.2.3", Name: "prog", Output: &b},
+		"flag", &flag)
+	assert.NoError(t, err)
+	a, err := p.GetOptions(args)
+
+	assert.ErrorIs(t, err, ErrVersion)
+	assert.False(t, flag)
+	assert.Equal(t, a, args)
+	assert.Equal(t, b.String(), "prog 1.2.3\n")
+}
+
+func TestVersion_InUsage(t *testing.T) {
+	var b bytes.Buffer
+
+	p, err := NewParser(Config{Version: "1.2.3", Name: "prog"})
+	assert.NoError(t, err)
+	err = p.WriteUsage(&b)
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "  --version             Show the version and exit.\n")
+}
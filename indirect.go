@@ -0,0 +1,72 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Indirect lets values of the named option be read from somewhere else,
+// which keeps them out of process listings:
+//
+//   - @path reads the value from the file at path.
+//   - "-" reads the value from [Config.Stdin], at most once per parse.
+//   - env:NAME reads the value from the environment variable NAME.
+//
+// Trailing newlines are trimmed from values read from files or stdin.  The
+// value read is then converted as if it had been given directly.  Any
+// other value is used as it is, so the option can't take values which
+// start with "@" or "env:", or which are just "-".
+func (p *Parser) Indirect(name string) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if spec.argType == optionNoArg {
+		return errors.New("option takes no value")
+	}
+	spec.indirect = true
+	return nil
+}
+
+// stdin returns where "-" is read from.
+func (oc *optionCollection) stdin() io.Reader {
+	if oc.config.Stdin != nil {
+		return oc.config.Stdin
+	}
+	return os.Stdin
+}
+
+// resolve returns the value which value refers to, for an option using
+// Indirect, and where it came from, or "" if value is used as it is.
+func (oc *optionCollection) resolve(plan *Plan, spec *optionSpec, value string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(value, "@"):
+		path := value[1:]
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("option %s: %w", spec.name, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), path, nil
+	case value == "-":
+		if plan.readStdin {
+			return "", "", fmt.Errorf("option %s: stdin already read", spec.name)
+		}
+		plan.readStdin = true
+		b, err := io.ReadAll(oc.stdin())
+		if err != nil {
+			return "", "", fmt.Errorf("option %s: reading stdin: %w", spec.name, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), "stdin", nil
+	case strings.HasPrefix(value, "env:"):
+		name := value[len("env:"):]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", "", fmt.Errorf("option %s: environment variable %s not set", spec.name, name)
+		}
+		return v, "environment variable " + name, nil
+	}
+	return value, "", nil
+}
@@ -0,0 +1,120 @@
+package getopt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// indirectParser returns a parser with token and port options using
+// Indirect, reading stdin from stdin.
+func indirectParser(t *testing.T, stdin string, token *string, port *int) *Parser {
+	p, err := NewParser(Config{Stdin: strings.NewReader(stdin)},
+		"token=s", token, "port=i", port)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Indirect("token"))
+	assert.NoError(t, p.Indirect("port"))
+	return p
+}
+
+func TestIndirect_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tok")
+	assert.NoError(t, os.WriteFile(path, []byte("secret\n\n"), 0600))
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "", &token, &port)
+	r, err := p.Parse([]string{"--token=@" + path})
+
+	assert.NoError(t, err)
+	assert.Equal(t, token, "secret")
+	or, _ := r.Option("token")
+	assert.Equal(t, or.Values, []string{"@" + path})
+}
+
+func TestIndirect_Stdin(t *testing.T) {
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "8080\r\n", &token, &port)
+	_, err := p.GetOptions([]string{"--port", "-"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, port, 8080)
+}
+
+func TestIndirect_StdinTwice(t *testing.T) {
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "8080\n", &token, &port)
+	_, err := p.GetOptions([]string{"--port=-", "--token=-"})
+
+	assert.ErrorContains(t, err, "option token: stdin already read")
+}
+
+func TestIndirect_Env(t *testing.T) {
+	t.Setenv("GETOPT_TEST_TOKEN", "secret")
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "", &token, &port)
+	_, err := p.GetOptions([]string{"--token", "env:GETOPT_TEST_TOKEN"})
+	assert.NoError(t, err)
+	assert.Equal(t, token, "secret")
+
+	_, err = p.GetOptions([]string{"--token", "env:GETOPT_TEST_UNSET"})
+	assert.ErrorContains(t, err, "option token: environment variable GETOPT_TEST_UNSET not set")
+}
+
+// Other values are used as they are.
+func TestIndirect_Plain(t *testing.T) {
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "", &token, &port)
+	_, err := p.GetOptions([]string{"--token=plain", "--port=80"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, token, "plain")
+	assert.Equal(t, port, 80)
+}
+
+// Errors name the option and where the value came from.
+func TestIndirect_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "port")
+	assert.NoError(t, os.WriteFile(path, []byte("eighty\n"), 0600))
+	missing := filepath.Join(dir, "missing")
+	token := ""
+	port := 0
+
+	p := indirectParser(t, "", &token, &port)
+	_, err1 := p.GetOptions([]string{"--port=@" + path})
+	_, err2 := p.GetOptions([]string{"--token=@" + missing})
+
+	assert.ErrorContains(t, err1, "option port: value from "+path+": ")
+	assert.ErrorContains(t, err2, "option token: open "+missing+": ")
+}
+
+// Without Indirect, values are not read.
+func TestIndirect_Off(t *testing.T) {
+	token := ""
+
+	_, err := GetOptions([]string{"--token=@/nonexistent"}, "token=s", &token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, token, "@/nonexistent")
+}
+
+func TestIndirect_Unknown(t *testing.T) {
+	verbose := false
+
+	p, err := NewParser(Config{}, "verbose", &verbose)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Indirect("other"), "Arg other not recognized")
+	assert.ErrorContains(t, p.Indirect("verbose"), "option takes no value")
+}
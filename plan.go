@@ -0,0 +1,193 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Operation says how an [Assignment] changes its target.
+type Operation int
+
+const (
+	// OpSet stores Value in the target.
+	OpSet Operation = iota
+
+	// OpAppend appends Value to the slice the target points to.
+	OpAppend
+
+	// OpIncrement adds one to the integer the target points to.
+	OpIncrement
+
+	// OpCall calls the function in the target with Value.
+	OpCall
+
+	// OpClear empties the slice the target points to.
+	OpClear
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OpSet:
+		return "set"
+	case OpAppend:
+		return "append"
+	case OpIncrement:
+		return "increment"
+	case OpCall:
+		return "call"
+	case OpClear:
+		return "clear"
+	}
+	return "unknown"
+}
+
+// Assignment describes one change which a [Plan] will make.
+type Assignment struct {
+	// Option is the option's name from its descriptor, or "<>" for a
+	// non-option argument.
+	Option string
+
+	// Target is the pointer passed with the descriptor, which will be
+	// changed.
+	Target any
+
+	// Value is the typed value to store or append, or nil for
+	// OpIncrement and OpClear.  For a [flag.Value] target, it is the string which
+	// will be passed to Set.  For OpCall, it is the argument.
+	Value any
+
+	Op Operation
+
+	// Sensitive is set for options marked with [Parser.Sensitive].  String
+	// hides their values.
+	Sensitive bool
+}
+
+func (a Assignment) String() string {
+	if a.Op == OpIncrement || a.Op == OpClear {
+		return fmt.Sprintf("%s %s", a.Op, a.Option)
+	} else if a.Sensitive {
+		return fmt.Sprintf("%s %s %s", a.Op, a.Option, redacted)
+	}
+	return fmt.Sprintf("%s %s %v", a.Op, a.Option, a.Value)
+}
+
+// Plan holds the changes which arguments ask for, from [Parser.Plan].
+type Plan struct {
+	// Result reports how each option was given, and the remaining
+	// arguments.
+	Result *Result
+
+	// Assignments lists the changes in the order they will be made.
+	Assignments []Assignment
+
+	// Warnings lists problems which don't stop parsing, such as use of
+	// deprecated options.  Apply writes them to [Config.Warnings].
+	Warnings []string
+
+	pending   []pendingCommit
+	applied   bool
+	readStdin bool
+
+	// Where each option was first given, by index in the collection's
+	// specs, or -1.  Only options whose policies depend on it are
+	// tracked.
+	first []int
+}
+
+// pendingCommit is a change, and the option which asked for it, or nil for
+// a non-option argument.
+type pendingCommit struct {
+	spec *optionSpec
+	c    optionCommitter
+}
+
+// newPlan makes a Plan with room for options from n arguments.
+func newPlan(oc *optionCollection, n int) *Plan {
+	return &Plan{
+		Result:  newResult(oc, n),
+		pending: make([]pendingCommit, 0, n),
+	}
+}
+
+func (pl *Plan) add(spec *optionSpec, c optionCommitter) {
+	pl.pending = append(pl.pending, pendingCommit{spec, c})
+}
+
+// noteFirst records pos as where spec was given, unless it was given
+// earlier.
+func (pl *Plan) noteFirst(spec *optionSpec, pos int) {
+	if pl.first == nil {
+		pl.first = make([]int, len(pl.Result.oc.specs))
+		for i := range pl.first {
+			pl.first[i] = -1
+		}
+	}
+	if pl.first[spec.index] < 0 {
+		pl.first[spec.index] = pos
+	}
+}
+
+// firstPosition returns where spec was first given, if spec has a policy
+// which depends on it.
+func (pl *Plan) firstPosition(spec *optionSpec) (int, bool) {
+	if pl.first == nil || pl.first[spec.index] < 0 {
+		return 0, false
+	}
+	return pl.first[spec.index], true
+}
+
+// given reports whether spec was given before, if spec has a policy which
+// depends on it.
+func (pl *Plan) given(spec *optionSpec) bool {
+	_, ok := pl.firstPosition(spec)
+	return ok
+}
+
+// warn adds msg to Warnings, unless it is already there.
+func (pl *Plan) warn(msg string) {
+	if !slices.Contains(pl.Warnings, msg) {
+		pl.Warnings = append(pl.Warnings, msg)
+	}
+}
+
+// describe fills in Assignments.  Parsing which doesn't return the Plan
+// skips this.
+func (pl *Plan) describe() {
+	for _, p := range pl.pending {
+		a := p.c.assignment()
+		if p.spec != nil {
+			a.Option = p.spec.name
+			a.Sensitive = p.spec.sensitive
+		}
+		pl.Assignments = append(pl.Assignments, a)
+	}
+}
+
+// Apply makes the changes in the plan.  A plan can only be applied once.
+// If a [flag.Value] which couldn't be checked in advance rejects its
+// value, or a "<>" function returns an error, Apply stops there, and
+// earlier changes stay made.
+func (pl *Plan) Apply() error {
+	if pl.applied {
+		return errors.New("plan already applied")
+	}
+	pl.applied = true
+
+	for _, msg := range pl.Warnings {
+		if _, err := fmt.Fprintf(pl.Result.oc.warnings(), "warning: %s\n", msg); err != nil {
+			return err
+		}
+	}
+	for _, p := range pl.pending {
+		err := p.c.commit()
+		if err != nil && p.spec != nil && p.spec.sensitive {
+			err = redact(err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
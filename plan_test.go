@@ -0,0 +1,76 @@
+package getopt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlan_Base(t *testing.T) {
+	args := []string{"--length=10", "--tag", "a", "--count", "--noverbose", "rest"}
+	length := 24
+	tags := []string{}
+	count := 0
+	verbose := true
+
+	p, err := NewParser(Config{},
+		"length", &length, "tag@", &tags, "count+", &count, "verbose!", &verbose)
+	assert.NoError(t, err)
+	plan, err := p.Plan(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, plan.Assignments, []Assignment{
+		{Option: "length", Target: &length, Value: 10, Op: OpSet},
+		{Option: "tag", Target: &tags, Value: "a", Op: OpAppend},
+		{Option: "count", Target: &count, Op: OpIncrement},
+		{Option: "verbose", Target: &verbose, Value: false, Op: OpSet},
+	})
+	assert.Equal(t, plan.Result.Args, args[5:])
+	assert.True(t, plan.Result.Seen("tag"))
+
+	// Nothing changes until the plan is applied.
+	assert.Equal(t, length, 24)
+	assert.Empty(t, tags)
+	assert.Equal(t, count, 0)
+	assert.True(t, verbose)
+
+	err = plan.Apply()
+
+	assert.NoError(t, err)
+	assert.Equal(t, length, 10)
+	assert.Equal(t, tags, []string{"a"})
+	assert.Equal(t, count, 1)
+	assert.False(t, verbose)
+}
+
+func TestPlan_ApplyTwice(t *testing.T) {
+	count := 0
+
+	p, err := NewParser(Config{}, "count+", &count)
+	assert.NoError(t, err)
+	plan, err := p.Plan([]string{"--count"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, plan.Apply())
+	assert.ErrorContains(t, plan.Apply(), "plan already applied")
+	assert.Equal(t, count, 1)
+}
+
+func TestPlan_Error(t *testing.T) {
+	value := 3
+
+	p, err := NewParser(Config{}, "value", &value)
+	assert.NoError(t, err)
+	plan, err := p.Plan([]string{"--value", "--other"})
+
+	assert.ErrorContains(t, err, "invalid syntax")
+	assert.Nil(t, plan)
+}
+
+func TestAssignment_String(t *testing.T) {
+	length := 0
+	count := 0
+
+	assert.Equal(t, Assignment{Option: "length", Target: &length, Value: 10, Op: OpSet}.String(), "set length 10")
+	assert.Equal(t, Assignment{Option: "count", Target: &count, Op: OpIncrement}.String(), "increment count")
+	assert.Equal(t, OpAppend.String(), "append")
+}
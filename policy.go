@@ -0,0 +1,81 @@
+package getopt
+
+import (
+	"errors"
+	"flag"
+)
+
+// ArrayPolicy says how values given for an array option combine with what
+// the slice held before parsing, such as defaults.
+type ArrayPolicy int
+
+const (
+	// Append adds values to whatever the slice held.
+	Append ArrayPolicy = iota
+
+	// ReplaceDefaults empties the slice before the first value is added,
+	// so values given replace the defaults.
+	ReplaceDefaults
+
+	// ClearOnEmpty appends like Append, except that an empty value, as in
+	// --conf=, empties the slice.
+	ClearOnEmpty
+)
+
+// SetArrayPolicy sets how values given for the named array option combine
+// with what the slice held.  The default is [Append].
+func (p *Parser) SetArrayPolicy(name string, policy ArrayPolicy) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if !spec.array {
+		return errors.New("option is not an array")
+	}
+	spec.arrayPolicy = policy
+	return nil
+}
+
+// RepeatPolicy says what happens when an option which holds one value,
+// rather than an array or count, is given more than once.
+type RepeatPolicy int
+
+const (
+	// LastWins keeps the last value given.
+	LastWins RepeatPolicy = iota
+
+	// FirstWins keeps the first value given, ignoring later ones.
+	FirstWins
+
+	// ErrorOnRepeat makes repeating the option an error, which reports
+	// where it was given.  Negated names count as the same option.
+	ErrorOnRepeat
+)
+
+// SetRepeatPolicy sets what happens when the named option is given more
+// than once, overriding [Config.RepeatPolicy].
+func (p *Parser) SetRepeatPolicy(name string, policy RepeatPolicy) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if spec.array || spec.counting {
+		return errors.New("option is not a scalar")
+	}
+	spec.repeatPolicy = policy
+	spec.hasRepeatPolicy = true
+	return nil
+}
+
+// repeatPolicy returns the policy for spec.
+func (oc *optionCollection) repeatPolicy(spec *optionSpec) RepeatPolicy {
+	if spec.hasRepeatPolicy {
+		return spec.repeatPolicy
+	}
+	// Other flag.Values may collect values, like arrays.
+	v, isValue := spec.ptr.(flag.Value)
+	if spec.array || spec.counting || spec.ptr == nil || (isValue && !isScalarValue(v)) {
+		return LastWins
+	}
+	return oc.config.RepeatPolicy
+}
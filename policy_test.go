@@ -0,0 +1,199 @@
+package getopt
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestArrayPolicy_Append(t *testing.T) {
+	conf := []string{"a.conf"}
+
+	_, err := GetOptions([]string{"--conf", "b.conf"}, "conf=s@", &conf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, conf, []string{"a.conf", "b.conf"})
+}
+
+func TestArrayPolicy_ReplaceDefaults(t *testing.T) {
+	args := []string{"--conf", "b.conf", "--conf=c.conf"}
+	conf := []string{"a.conf"}
+
+	p, err := NewParser(Config{}, "conf=s@", &conf)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetArrayPolicy("conf", ReplaceDefaults))
+	_, err = p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, conf, []string{"b.conf", "c.conf"})
+}
+
+// Without the option, the defaults are kept.
+func TestArrayPolicy_ReplaceNotGiven(t *testing.T) {
+	conf := []string{"a.conf"}
+
+	p, err := NewParser(Config{}, "conf=s@", &conf)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetArrayPolicy("conf", ReplaceDefaults))
+	_, err = p.GetOptions([]string{"rest"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, conf, []string{"a.conf"})
+}
+
+func TestArrayPolicy_ClearOnEmpty(t *testing.T) {
+	args := []string{"--conf", "b.conf", "--conf=", "--conf", "c.conf"}
+	conf := []string{"a.conf"}
+
+	p, err := NewParser(Config{}, "conf=s@", &conf)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetArrayPolicy("conf", ClearOnEmpty))
+	plan, err := p.Plan(args)
+	assert.NoError(t, err)
+
+	assert.Equal(t, plan.Assignments[1], Assignment{Option: "conf", Target: &conf, Op: OpClear})
+	assert.Equal(t, plan.Assignments[1].String(), "clear conf")
+	assert.NoError(t, plan.Apply())
+	assert.Equal(t, conf, []string{"c.conf"})
+}
+
+// An empty value is converted like any other without ClearOnEmpty.
+func TestArrayPolicy_EmptyInt(t *testing.T) {
+	ints := []int{1}
+
+	p, err := NewParser(Config{}, "ints=i@", &ints)
+	assert.NoError(t, err)
+	_, err1 := p.GetOptions([]string{"--ints="})
+	assert.NoError(t, p.SetArrayPolicy("ints", ClearOnEmpty))
+	_, err2 := p.GetOptions([]string{"--ints="})
+
+	assert.ErrorContains(t, err1, "invalid syntax")
+	assert.NoError(t, err2)
+	assert.Empty(t, ints)
+}
+
+func TestArrayPolicy_Errors(t *testing.T) {
+	conf := []string{}
+	name := ""
+
+	p, err := NewParser(Config{}, "conf=s@", &conf, "name=s", &name)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.SetArrayPolicy("other", Append), "Arg other not recognized")
+	assert.ErrorContains(t, p.SetArrayPolicy("name", Append), "option is not an array")
+}
+
+func TestRepeatPolicy_LastWins(t *testing.T) {
+	length := 0
+
+	_, err := GetOptions([]string{"--length", "1", "--length", "2"}, "length=i", &length)
+
+	assert.NoError(t, err)
+	assert.Equal(t, length, 2)
+}
+
+func TestRepeatPolicy_FirstWins(t *testing.T) {
+	args := []string{"--length", "1", "--length", "2"}
+	length := 0
+
+	p, err := NewParser(Config{RepeatPolicy: FirstWins}, "length=i", &length)
+	assert.NoError(t, err)
+	r, err := p.Parse(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, length, 1)
+	or, _ := r.Option("length")
+	assert.Equal(t, or.Values, []string{"1", "2"})
+}
+
+func TestRepeatPolicy_Error(t *testing.T) {
+	args := []string{"--target", "a", "--verbose", "--target=b"}
+	target := ""
+	verbose := false
+
+	p, err := NewParser(Config{}, "target=s", &target, "verbose", &verbose)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetRepeatPolicy("target", ErrorOnRepeat))
+	_, err = p.GetOptions(args)
+
+	assert.ErrorContains(t, err, "option target given more than once, at arguments 0 and 3")
+	assert.Equal(t, target, "")
+}
+
+// Negated names are the same option.
+func TestRepeatPolicy_Negated(t *testing.T) {
+	verbose := false
+
+	p, err := NewParser(Config{RepeatPolicy: ErrorOnRepeat}, "verbose!", &verbose)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--verbose", "--noverbose"})
+
+	assert.ErrorContains(t, err, "option verbose given more than once, at arguments 0 and 1")
+}
+
+// Arrays, counts and flag.Values which may collect values are not affected
+// by Config.
+func TestRepeatPolicy_NotScalar(t *testing.T) {
+	args := []string{"--tag=a", "--tag=b", "--count", "--count", "--level=1", "--level=2"}
+	tags := []string{}
+	count := 0
+	var levels []string
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Func("level", "", func(s string) error {
+		levels = append(levels, s)
+		return nil
+	})
+
+	p, err := NewParser(Config{RepeatPolicy: ErrorOnRepeat},
+		"tag=s@", &tags, "count+", &count, "level", fs.Lookup("level").Value)
+	assert.NoError(t, err)
+	_, err = p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, tags, []string{"a", "b"})
+	assert.Equal(t, count, 2)
+	assert.Equal(t, levels, []string{"1", "2"})
+}
+
+// flag.Values holding one value follow Config, like other scalars.
+func TestRepeatPolicy_ScalarValue(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	target := fs.String("target", "", "")
+
+	p, err := NewParser(Config{RepeatPolicy: ErrorOnRepeat}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--target=a", "--target=b"})
+
+	assert.ErrorContains(t, err, "option target given more than once, at arguments 0 and 1")
+	assert.Equal(t, *target, "")
+}
+
+// A policy for one option overrides Config.
+func TestRepeatPolicy_Override(t *testing.T) {
+	length := 0
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	level := fs.Int("level", 0, "")
+
+	p, err := NewParser(Config{RepeatPolicy: ErrorOnRepeat},
+		"length=i", &length, "level", fs.Lookup("level").Value)
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetRepeatPolicy("length", LastWins))
+	assert.NoError(t, p.SetRepeatPolicy("level", FirstWins))
+	_, err = p.GetOptions([]string{"--length=1", "--length=2", "--level=1", "--level=2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, length, 2)
+	assert.Equal(t, *level, 1)
+}
+
+func TestRepeatPolicy_Errors(t *testing.T) {
+	tags := []string{}
+	count := 0
+
+	p, err := NewParser(Config{}, "tag=s@", &tags, "count+", &count)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.SetRepeatPolicy("other", FirstWins), "Arg other not recognized")
+	assert.ErrorContains(t, p.SetRepeatPolicy("tag", FirstWins), "option is not a scalar")
+	assert.ErrorContains(t, p.SetRepeatPolicy("count", FirstWins), "option is not a scalar")
+}
@@ -0,0 +1,153 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// positional is a parameter declared with [Parser.Positional].
+type positional struct {
+	spec     *optionSpec
+	handler  optionHandler
+	optional bool
+}
+
+// Positional declares parameters for the arguments which remain after
+// options, with descriptors and pointers in pairs like [NewParser].  A
+// descriptor names the parameter and gives its type, such as "src=s" or
+// "count" with an int pointer.  A trailing "?" makes the parameter
+// optional, and an array descriptor such as "files=s@" takes every
+// remaining argument, so it must come last.  Required parameters must come
+// before optional ones.
+//
+// Values are converted in the same way as option values, and committed
+// after them.  It is an error to give fewer arguments than there are
+// required parameters, or more than there are parameters, unless the last
+// one is an array.  Positional can't be used with "<>".
+func (p *Parser) Positional(a ...any) error {
+	oc := p.oc
+	if oc.arguments != nil {
+		return errors.New(`positional parameters can't be used with "<>"`)
+	}
+	if oc.paramOC == nil {
+		oc.paramOC = newOptionCollection(Config{})
+	}
+
+	for ; len(a) >= 2; a = a[2:] {
+		desc, ok := a[0].(string)
+		if !ok {
+			return errors.New("descriptor must be string")
+		}
+		if err := oc.addPositional(desc, a[1]); err != nil {
+			return err
+		}
+	}
+	if len(a) == 1 {
+		return errors.New("odd number of arguments")
+	}
+	return nil
+}
+
+// addPositional adds one parameter, using a separate collection so that
+// the parameter's name is not also an option.
+func (oc *optionCollection) addPositional(desc string, ptr any) error {
+	optional := strings.HasSuffix(desc, "?")
+	desc = strings.TrimSuffix(desc, "?")
+	// Descriptors for arrays always end in "@".
+	array := strings.HasSuffix(desc, "@")
+
+	if n := len(oc.params); n > 0 {
+		last := oc.params[n-1]
+		if last.spec.array {
+			return errors.New("array parameter must be last")
+		} else if last.optional && !optional && !array {
+			return errors.New("required parameter after optional parameter")
+		}
+	}
+
+	if desc == argumentDescriptor {
+		return errors.New(`positional parameters can't be used with "<>"`)
+	}
+	pc := oc.paramOC
+	n := len(pc.specs)
+	if err := parseOption(pc, desc, ptr); err != nil {
+		return err
+	} else if len(pc.specs) == n {
+		return errors.New("descriptor not understood")
+	}
+	spec := pc.specs[n]
+	h, _ := pc.lookup(spec.name)
+	if spec.argType != optionRequiredArg {
+		// Flags, counters and optional values have nothing to parse.
+		return errors.New("descriptor type mismatch")
+	}
+
+	oc.params = append(oc.params, positional{spec, h, optional})
+	return nil
+}
+
+// plural returns "n noun", adding "s" unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// processPositionals adds changes for the parameters to plan, and returns
+// any arguments left over.
+func (oc *optionCollection) processPositionals(plan *Plan, rest []string) ([]string, error) {
+	if len(oc.params) == 0 {
+		return rest, nil
+	}
+
+	required := 0
+	for _, param := range oc.params {
+		if !param.optional && !param.spec.array {
+			required++
+		}
+	}
+	last := oc.params[len(oc.params)-1]
+	if len(rest) < required {
+		return nil, fmt.Errorf("expected at least %s", plural(required, "argument"))
+	} else if !last.spec.array && len(rest) > len(oc.params) {
+		return nil, fmt.Errorf("expected at most %s", plural(len(oc.params), "argument"))
+	}
+
+	for _, param := range oc.params {
+		if len(rest) == 0 {
+			break
+		}
+		n := 1
+		if param.spec.array {
+			n = len(rest)
+		}
+		for _, arg := range rest[:n] {
+			c, err := param.handler.handle([]string{arg})
+			if err != nil {
+				return nil, fmt.Errorf("argument %s: %w", param.spec.name, err)
+			}
+			plan.add(param.spec, c)
+		}
+		rest = rest[n:]
+	}
+	return rest, nil
+}
+
+// positionalSynopsis renders the parameters, such as "src [count]
+// [files...]".
+func (oc *optionCollection) positionalSynopsis() string {
+	var words []string
+	for _, param := range oc.params {
+		switch {
+		case param.spec.array:
+			words = append(words, "["+param.spec.name+"...]")
+		case param.optional:
+			words = append(words, "["+param.spec.name+"]")
+		default:
+			words = append(words, param.spec.name)
+		}
+	}
+	return strings.Join(words, " ")
+}
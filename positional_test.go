@@ -0,0 +1,147 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPositional_Base(t *testing.T) {
+	args := []string{"--verbose", "in.txt", "3", "a", "b"}
+	verbose := false
+	src := ""
+	count := 1
+	files := []string{}
+
+	p, err := NewParser(Config{}, "verbose", &verbose)
+	assert.NoError(t, err)
+	err = p.Positional("src=s", &src, "count=i?", &count, "files=s@", &files)
+	assert.NoError(t, err)
+	rest, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.True(t, verbose)
+	assert.Equal(t, src, "in.txt")
+	assert.Equal(t, count, 3)
+	assert.Equal(t, files, []string{"a", "b"})
+}
+
+func TestPositional_Optional(t *testing.T) {
+	src := ""
+	count := 1
+	files := []string{}
+
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	err = p.Positional("src", &src, "count?", &count, "files@", &files)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"in.txt"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, src, "in.txt")
+	assert.Equal(t, count, 1)
+	assert.Empty(t, files)
+}
+
+func TestPositional_Arity(t *testing.T) {
+	src := ""
+	dst := ""
+	count := 0
+
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	err = p.Positional("src=s", &src, "dst=s", &dst, "count=i?", &count)
+	assert.NoError(t, err)
+
+	_, err = p.GetOptions([]string{"a"})
+	assert.ErrorContains(t, err, "expected at least 2 arguments")
+	_, err = p.GetOptions([]string{"a", "b", "1", "c"})
+	assert.ErrorContains(t, err, "expected at most 3 arguments")
+	assert.Equal(t, src, "")
+}
+
+func TestPositional_Convert(t *testing.T) {
+	count := 0
+
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	err = p.Positional("count=i", &count)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"x"})
+
+	assert.ErrorContains(t, err, "argument count: ")
+	assert.ErrorContains(t, err, "invalid syntax")
+}
+
+// Parameter names are not options.
+func TestPositional_NotOption(t *testing.T) {
+	src := ""
+
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	err = p.Positional("src=s", &src)
+	assert.NoError(t, err)
+	_, err = p.GetOptions([]string{"--src=x"})
+
+	assert.ErrorContains(t, err, "Arg src not recognized")
+}
+
+func TestPositional_Errors(t *testing.T) {
+	var name string
+	var verbose bool
+	var count int
+	var files []string
+	callback := func(string) error { return nil }
+
+	for msg, a := range map[string][]any{
+		"array parameter must be last":                  {"files@", &files, "name", &name},
+		"required parameter after optional parameter":   {"name?", &name, "count", &count},
+		"descriptor type mismatch":                      {"verbose", &verbose},
+		"option already exists":                         {"name", &name, "name", &name},
+		"odd number of arguments":                       {"name"},
+		`positional parameters can't be used with "<>"`: nil,
+	} {
+		p, err := NewParser(Config{}, "<>", callback)
+		if a != nil {
+			p, err = NewParser(Config{})
+		}
+		assert.NoError(t, err)
+
+		err = p.Positional(a...)
+
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+// "<>" is not a parameter.
+func TestPositional_Arguments(t *testing.T) {
+	var src string
+	callback := func(string) error { return nil }
+
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	err1 := p.Positional("<>", callback)
+	err2 := p.Positional("src", &src, "<>", callback)
+
+	assert.ErrorContains(t, err1, `positional parameters can't be used with "<>"`)
+	assert.ErrorContains(t, err2, `positional parameters can't be used with "<>"`)
+	assert.Len(t, p.oc.params, 1)
+	assert.Nil(t, p.oc.arguments)
+	assert.Nil(t, p.oc.paramOC.arguments)
+}
+
+func TestPositional_Synopsis(t *testing.T) {
+	var b bytes.Buffer
+	src := ""
+	count := 0
+	files := []string{}
+
+	p, err := NewParser(Config{Name: "prog", Help: true})
+	assert.NoError(t, err)
+	err = p.Positional("src=s", &src, "count=i?", &count, "files=s@", &files)
+	assert.NoError(t, err)
+	p.WriteUsage(&b)
+
+	assert.Contains(t, b.String(), "Usage: prog [options] [--] src [count] [files...]\n")
+}
@@ -0,0 +1,132 @@
+package getopt
+
+// Source says where an option's value came from.
+type Source int
+
+const (
+	// SourceDefault means the option was not given, so the variable
+	// still holds whatever it held before parsing.
+	SourceDefault Source = iota
+
+	// SourceCommandLine means the option was given in the arguments.
+	SourceCommandLine
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceCommandLine:
+		return "command line"
+	}
+	return "unknown"
+}
+
+// OptionResult reports how one option was given.
+type OptionResult struct {
+	// Name is the option's name from its descriptor, even if it was
+	// given by another name, such as a negated name.
+	Name string
+
+	Source Source
+
+	// Count is the number of times the option was given.
+	Count int
+
+	// Values holds each value given for the option, in order, as it
+	// appeared in the arguments.  Options given without a value add
+	// nothing.  Values of options marked with [Parser.Sensitive] are
+	// "[redacted]".
+	Values []string
+
+	// Positions holds the index in the arguments of each time the option
+	// was given.
+	Positions []int
+}
+
+// occurrence records one time an option was given.
+type occurrence struct {
+	spec     *optionSpec
+	pos      int
+	value    string
+	hasValue bool
+}
+
+// Result reports the outcome of [Parser.Parse].
+type Result struct {
+	// Args holds the arguments remaining after options.
+	Args []string
+
+	oc   *optionCollection
+	seen []occurrence
+}
+
+// newResult makes a Result with room for options from n arguments.
+func newResult(oc *optionCollection, n int) *Result {
+	return &Result{
+		oc:   oc,
+		seen: make([]occurrence, 0, n),
+	}
+}
+
+// record notes that spec was given at pos, with the values in zeroOrOne.
+func (r *Result) record(spec *optionSpec, pos int, zeroOrOne []string) {
+	o := occurrence{spec: spec, pos: pos}
+	if len(zeroOrOne) > 0 {
+		o.value = zeroOrOne[0]
+		o.hasValue = true
+	}
+	r.seen = append(r.seen, o)
+}
+
+func (r *Result) result(spec *optionSpec) OptionResult {
+	or := OptionResult{Name: spec.name, Source: SourceDefault}
+	for _, o := range r.seen {
+		if o.spec != spec {
+			continue
+		}
+		or.Source = SourceCommandLine
+		or.Count++
+		if o.hasValue && spec.sensitive {
+			or.Values = append(or.Values, redacted)
+		} else if o.hasValue {
+			or.Values = append(or.Values, o.value)
+		}
+		or.Positions = append(or.Positions, o.pos)
+	}
+	return or
+}
+
+// Option reports how the named option was given.  Any name the option
+// accepts can be used, such as a negated name.  Returns false if there is
+// no such option.
+func (r *Result) Option(name string) (OptionResult, bool) {
+	spec, ok := r.oc.lookupSpec(name)
+	if !ok {
+		return OptionResult{}, false
+	}
+	return r.result(spec), true
+}
+
+// Seen reports whether the named option was given in the arguments.
+func (r *Result) Seen(name string) bool {
+	spec, ok := r.oc.lookupSpec(name)
+	if !ok {
+		return false
+	}
+	for _, o := range r.seen {
+		if o.spec == spec {
+			return true
+		}
+	}
+	return false
+}
+
+// Options reports on every option, in the order they were described.
+func (r *Result) Options() []OptionResult {
+	var ors []OptionResult
+	for _, spec := range r.oc.specs {
+		ors = append(ors, r.result(spec))
+	}
+	return ors
+}
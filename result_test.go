@@ -0,0 +1,110 @@
+package getopt
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResult_Base(t *testing.T) {
+	args := []string{"--length", "24", "--tag=a", "--verbose", "--tag", "b", "rest"}
+	length := 24
+	width := 80
+	tags := []string{}
+	verbose := false
+
+	p, err := NewParser(Config{},
+		"length", &length, "width", &width, "tag@", &tags, "verbose", &verbose)
+	assert.NoError(t, err)
+	r, err := p.Parse(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, r.Args, args[6:])
+	assert.True(t, r.Seen("length"))
+	assert.False(t, r.Seen("width"))
+
+	or, ok := r.Option("length")
+	assert.True(t, ok)
+	assert.Equal(t, or, OptionResult{
+		Name:      "length",
+		Source:    SourceCommandLine,
+		Count:     1,
+		Values:    []string{"24"},
+		Positions: []int{0},
+	})
+
+	or, ok = r.Option("width")
+	assert.True(t, ok)
+	assert.Equal(t, or, OptionResult{Name: "width", Source: SourceDefault})
+
+	or, _ = r.Option("tag")
+	assert.Equal(t, or.Count, 2)
+	assert.Equal(t, or.Values, []string{"a", "b"})
+	assert.Equal(t, or.Positions, []int{2, 4})
+
+	or, _ = r.Option("verbose")
+	assert.Equal(t, or.Count, 1)
+	assert.Empty(t, or.Values)
+	assert.Equal(t, or.Positions, []int{3})
+}
+
+// Negated names report on the option they negate.
+func TestResult_Negated(t *testing.T) {
+	flag := true
+
+	p, err := NewParser(Config{}, "flag!", &flag)
+	assert.NoError(t, err)
+	r, err := p.Parse([]string{"--flag", "--noflag"})
+
+	assert.NoError(t, err)
+	or, ok := r.Option("noflag")
+	assert.True(t, ok)
+	assert.Equal(t, or.Name, "flag")
+	assert.Equal(t, or.Count, 2)
+	assert.Equal(t, or.Positions, []int{0, 1})
+	assert.False(t, flag)
+}
+
+func TestResult_Unknown(t *testing.T) {
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	r, err := p.Parse([]string{"rest"})
+
+	assert.NoError(t, err)
+	_, ok := r.Option("other")
+	assert.False(t, ok)
+	assert.False(t, r.Seen("other"))
+}
+
+func TestResult_Options(t *testing.T) {
+	a := 0
+	b := 0
+
+	p, err := NewParser(Config{}, "b", &b, "a", &a)
+	assert.NoError(t, err)
+	r, err := p.Parse([]string{"--a=1"})
+
+	assert.NoError(t, err)
+	ors := r.Options()
+	assert.Len(t, ors, 2)
+	assert.Equal(t, ors[0].Name, "b")
+	assert.Equal(t, ors[0].Source, SourceDefault)
+	assert.Equal(t, ors[1].Name, "a")
+	assert.Equal(t, ors[1].Source, SourceCommandLine)
+}
+
+func TestResult_Error(t *testing.T) {
+	value := 3
+
+	p, err := NewParser(Config{}, "value", &value)
+	assert.NoError(t, err)
+	r, err := p.Parse([]string{"--value=x"})
+
+	assert.ErrorContains(t, err, "invalid syntax")
+	assert.Nil(t, r)
+	assert.Equal(t, value, 3)
+}
+
+func TestSource_String(t *testing.T) {
+	assert.Equal(t, SourceDefault.String(), "default")
+	assert.Equal(t, SourceCommandLine.String(), "command line")
+}
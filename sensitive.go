@@ -0,0 +1,51 @@
+package getopt
+
+import (
+	"errors"
+	"strconv"
+)
+
+// redacted stands in for the values of sensitive options.
+const redacted = "[redacted]"
+
+// Sensitive marks the named option as holding secrets, such as passwords.
+// Its values are replaced with "[redacted]" wherever the package shows
+// them: in errors, including conversion errors, in the defaults shown by
+// usage and documentation, in [Result] values, and in [Assignment]
+// strings.  The values are still stored as usual.
+func (p *Parser) Sensitive(name string) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if spec.argType == optionNoArg {
+		return errors.New("option takes no value")
+	}
+	spec.sensitive = true
+	return nil
+}
+
+// quote returns value quoted for messages, or "[redacted]" quoted if spec
+// is sensitive.
+func (spec *optionSpec) quote(value string) string {
+	if spec.sensitive {
+		return strconv.Quote(redacted)
+	}
+	return strconv.Quote(value)
+}
+
+// redact hides the value held by errors from converting a value of a
+// sensitive option.  These are conversion errors from strconv, which may
+// hold only part of the value, and values rejected by a [flag.Value].
+// Other messages are made with [optionSpec.quote].
+func redact(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		numErr.Num = redacted
+	}
+	var valueErr *valueError
+	if errors.As(err, &valueErr) {
+		valueErr.value = redacted
+	}
+	return err
+}
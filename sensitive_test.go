@@ -0,0 +1,188 @@
+package getopt
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestSensitive_ConvertError(t *testing.T) {
+	pin := 0
+
+	p, err := NewParser(Config{}, "pin=i", &pin)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pin"))
+	_, err = p.GetOptions([]string{"--pin=12x4"})
+
+	assert.ErrorContains(t, err, `parsing "[redacted]": invalid syntax`)
+	assert.NotContains(t, err.Error(), "12x4")
+	var numErr *strconv.NumError
+	assert.True(t, errors.As(err, &numErr))
+	assert.Equal(t, numErr.Num, "[redacted]")
+}
+
+// flag.Value errors happen on commit.
+func TestSensitive_CommitError(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Int("pin", 0, "")
+
+	p, err := NewParser(Config{}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pin"))
+	_, err = p.GetOptions([]string{"--pin", "12x4"})
+
+	assert.ErrorContains(t, err, `invalid value "[redacted]"`)
+	assert.NotContains(t, err.Error(), "12x4")
+}
+
+// Values which quoting would escape are hidden too.
+func TestSensitive_QuotedCommitError(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.Func("pw", "", func(string) error { return errors.New("rejected") })
+
+	p, err := NewParser(Config{}, FromFlagSet(fs)...)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pw"))
+	_, err = p.GetOptions([]string{"--pw", `hun"ter2`})
+
+	assert.EqualError(t, err, `invalid value "[redacted]": rejected`)
+}
+
+// Messages are not searched for the value, so a short value doesn't
+// change other text.
+func TestSensitive_ShortValue(t *testing.T) {
+	n := 0
+
+	p, err := NewParser(Config{}, "n=i", &n)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("n"))
+	_, err = p.GetOptions([]string{"--n", "x"})
+
+	assert.EqualError(t, err, `strconv.Atoi: parsing "[redacted]": invalid syntax`)
+}
+
+func TestSensitive_SplitError(t *testing.T) {
+	pins := []int{}
+
+	p, err := NewParser(Config{}, "pins=i@", &pins)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pins"))
+	assert.NoError(t, p.Split("pins", ",", KeepEmpty))
+	_, err = p.GetOptions([]string{"--pins=1234,56x8"})
+
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "56x8")
+}
+
+func TestSensitive_SplitEmpty(t *testing.T) {
+	pins := []string{}
+
+	p, err := NewParser(Config{}, "pins=s@", &pins)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pins"))
+	assert.NoError(t, p.Split("pins", ",", RejectEmpty))
+	_, err1 := p.GetOptions([]string{"--pins", "a\tb,,c"})
+	_, err2 := p.GetOptions([]string{"--pins", `a"b,,c`})
+
+	assert.EqualError(t, err1, `empty element in "[redacted]"`)
+	assert.EqualError(t, err2, `empty element in "[redacted]"`)
+}
+
+func TestSensitive_IndirectError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pin")
+	assert.NoError(t, os.WriteFile(path, []byte("12x4\n"), 0600))
+	pin := 0
+
+	p, err := NewParser(Config{}, "pin=i", &pin)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("pin"))
+	assert.NoError(t, p.Indirect("pin"))
+	_, err = p.GetOptions([]string{"--pin=@" + path})
+
+	assert.ErrorContains(t, err, "option pin: value from "+path+": ")
+	assert.NotContains(t, err.Error(), "12x4")
+}
+
+// A value which wasn't taken by an optional option isn't echoed.
+func TestSensitive_NotRecognized(t *testing.T) {
+	password := ""
+
+	p, err := NewParser(Config{}, "password:s", &password)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("password"))
+	_, err = p.GetOptions([]string{"--password", "--hunter2"})
+
+	assert.ErrorContains(t, err, "Arg [redacted] not recognized")
+}
+
+func TestSensitive_Usage(t *testing.T) {
+	var b bytes.Buffer
+	password := "hunter2"
+
+	p, err := NewParser(Config{Name: "prog"}, "password=s", &password)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("password"))
+	assert.NoError(t, p.WriteUsage(&b))
+
+	assert.NotContains(t, b.String(), "hunter2")
+}
+
+func TestSensitive_FlagSet(t *testing.T) {
+	var b bytes.Buffer
+	password := "hunter2"
+	pin := 1234
+
+	p, err := NewParser(Config{}, "password=s", &password, "pin=i", &pin)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("password"))
+	assert.NoError(t, p.Sensitive("pin"))
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.SetOutput(&b)
+	assert.NoError(t, p.ToFlagSet(fs))
+	fs.PrintDefaults()
+
+	assert.NotContains(t, b.String(), "hunter2")
+	assert.NotContains(t, b.String(), "1234")
+	assert.NotContains(t, b.String(), "default")
+
+	// The variables keep their values.
+	assert.NoError(t, fs.Parse(nil))
+	assert.Equal(t, password, "hunter2")
+	assert.Equal(t, pin, 1234)
+}
+
+func TestSensitive_Results(t *testing.T) {
+	password := ""
+
+	p, err := NewParser(Config{}, "password=s", &password)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Sensitive("password"))
+	plan, err := p.Plan([]string{"--password=hunter2"})
+	assert.NoError(t, err)
+
+	or, _ := plan.Result.Option("password")
+	assert.Equal(t, or.Values, []string{"[redacted]"})
+	assert.True(t, plan.Assignments[0].Sensitive)
+	assert.Equal(t, plan.Assignments[0].String(), "set password [redacted]")
+	assert.NotContains(t, fmt.Sprint(plan.Assignments), "hunter2")
+
+	// The value is still stored.
+	assert.NoError(t, plan.Apply())
+	assert.Equal(t, password, "hunter2")
+}
+
+func TestSensitive_Errors(t *testing.T) {
+	verbose := false
+
+	p, err := NewParser(Config{}, "verbose", &verbose)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Sensitive("other"), "Arg other not recognized")
+	assert.ErrorContains(t, p.Sensitive("verbose"), "option takes no value")
+}
@@ -0,0 +1,97 @@
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EmptyPolicy says what [Parser.Split] does with empty elements, as in
+// "a,,b".
+type EmptyPolicy int
+
+const (
+	// KeepEmpty passes empty elements to the option like any other.
+	KeepEmpty EmptyPolicy = iota
+
+	// SkipEmpty drops empty elements.
+	SkipEmpty
+
+	// RejectEmpty makes empty elements an error.
+	RejectEmpty
+)
+
+// splitter holds the settings from [Parser.Split].
+type splitter struct {
+	sep   string
+	empty EmptyPolicy
+}
+
+// Split makes each value of the named array option a list separated by
+// sep, so that --tags a,b,c appends three elements.  Elements are converted
+// one by one, as if each was given separately.  A backslash before sep
+// makes it part of an element, and a doubled backslash stands for one.
+// empty says what to do with empty elements.
+func (p *Parser) Split(name, sep string, empty EmptyPolicy) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	if !spec.array {
+		return errors.New("option is not an array")
+	}
+	if sep == "" {
+		return errors.New("separator is empty")
+	}
+	spec.split = &splitter{sep, empty}
+	return nil
+}
+
+// handle adds a change to plan for each element of value.
+func (s *splitter) handle(plan *Plan, spec *optionSpec, h optionHandler, value string) error {
+	elems, ok := s.split(value)
+	if !ok {
+		return fmt.Errorf("empty element in %s", spec.quote(value))
+	}
+	for i := range elems {
+		c, err := h.handle(elems[i : i+1])
+		if err != nil {
+			return err
+		}
+		plan.add(spec, c)
+	}
+	return nil
+}
+
+// split breaks value into elements.  Returns false if value has an empty
+// element which the splitter rejects.
+func (s *splitter) split(value string) ([]string, bool) {
+	var elems []string
+	var b strings.Builder
+	for i := 0; i <= len(value); {
+		switch {
+		case i == len(value) || strings.HasPrefix(value[i:], s.sep):
+			elem := b.String()
+			b.Reset()
+			if elem == "" && s.empty == RejectEmpty {
+				return nil, false
+			} else if elem != "" || s.empty == KeepEmpty {
+				elems = append(elems, elem)
+			}
+			if i == len(value) {
+				return elems, true
+			}
+			i += len(s.sep)
+		case value[i] == '\\' && strings.HasPrefix(value[i+1:], s.sep):
+			b.WriteString(s.sep)
+			i += 1 + len(s.sep)
+		case value[i] == '\\' && strings.HasPrefix(value[i+1:], `\`):
+			b.WriteByte('\\')
+			i += 2
+		default:
+			b.WriteByte(value[i])
+			i++
+		}
+	}
+	return elems, true
+}
@@ -0,0 +1,112 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplit_Base(t *testing.T) {
+	args := []string{"--tags", "a,b,c", "--tags=d", "rest"}
+	tags := []string{}
+
+	p, err := NewParser(Config{}, "tags=s@", &tags)
+	assert.NoError(t, err)
+	err = p.Split("tags", ",", KeepEmpty)
+	assert.NoError(t, err)
+	rest, err := p.GetOptions(args)
+
+	assert.NoError(t, err)
+	assert.Equal(t, rest, args[3:])
+	assert.Equal(t, tags, []string{"a", "b", "c", "d"})
+}
+
+func TestSplit_Typed(t *testing.T) {
+	ints := []int{}
+	floats := []float64{}
+
+	p, err := NewParser(Config{}, "ints=i@", &ints, "floats=f@", &floats)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Split("ints", ",", KeepEmpty))
+	assert.NoError(t, p.Split("floats", ":", KeepEmpty))
+	_, err = p.GetOptions([]string{"--ints=1,2,3", "--floats=1.5:-2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ints, []int{1, 2, 3})
+	assert.Equal(t, floats, []float64{1.5, -2})
+}
+
+// Elements are converted one by one, and nothing is committed on error.
+func TestSplit_TypedError(t *testing.T) {
+	ints := []int{}
+
+	p, err := NewParser(Config{}, "ints=i@", &ints)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Split("ints", ",", KeepEmpty))
+	_, err = p.GetOptions([]string{"--ints=1,x,3"})
+
+	assert.ErrorContains(t, err, `parsing "x": invalid syntax`)
+	assert.Empty(t, ints)
+}
+
+func TestSplit_Escape(t *testing.T) {
+	s := &splitter{",", KeepEmpty}
+
+	elems, ok := s.split(`a\,b,c\\,d\e`)
+
+	assert.True(t, ok)
+	assert.Equal(t, elems, []string{"a,b", `c\`, `d\e`})
+}
+
+func TestSplit_Empty(t *testing.T) {
+	keep := &splitter{",", KeepEmpty}
+	skip := &splitter{",", SkipEmpty}
+	reject := &splitter{",", RejectEmpty}
+
+	elems, ok := keep.split(",a,,b,")
+	assert.True(t, ok)
+	assert.Equal(t, elems, []string{"", "a", "", "b", ""})
+
+	elems, ok = skip.split(",a,,b,")
+	assert.True(t, ok)
+	assert.Equal(t, elems, []string{"a", "b"})
+
+	_, ok = reject.split("a,,b")
+	assert.False(t, ok)
+}
+
+func TestSplit_Reject(t *testing.T) {
+	tags := []string{}
+
+	p, err := NewParser(Config{}, "tags=s@", &tags)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Split("tags", ",", RejectEmpty))
+	_, err = p.GetOptions([]string{"--tags=a,,b"})
+
+	assert.ErrorContains(t, err, `empty element in "a,,b"`)
+	assert.Empty(t, tags)
+}
+
+func TestSplit_Errors(t *testing.T) {
+	tags := []string{}
+	name := ""
+
+	p, err := NewParser(Config{}, "tags=s@", &tags, "name=s", &name)
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, p.Split("other", ",", KeepEmpty), "Arg other not recognized")
+	assert.ErrorContains(t, p.Split("name", ",", KeepEmpty), "option is not an array")
+	assert.ErrorContains(t, p.Split("tags", "", KeepEmpty), "separator is empty")
+}
+
+func TestSplit_Usage(t *testing.T) {
+	var b bytes.Buffer
+	tags := []string{}
+
+	p, err := NewParser(Config{}, "tags=s@", &tags)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Split("tags", ",", KeepEmpty))
+	p.WriteUsage(&b)
+
+	assert.Contains(t, b.String(), `May be given more than once. Values may be separated by ",".`)
+}
@@ -0,0 +1,211 @@
+package getopt
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Describe sets the help text which documentation generated by the
+// [Parser] shows for the named option.
+func (p *Parser) Describe(name, help string) error {
+	spec, err := p.spec(name)
+	if err != nil {
+		return err
+	}
+	spec.help = help
+	return nil
+}
+
+// formatDefault renders the value ptr points to, or "" for zero values,
+// which aren't worth mentioning.
+func formatDefault(ptr any) string {
+	switch f := ptr.(type) {
+	case *bool:
+		if *f {
+			return "true"
+		}
+	case *int:
+		if *f != 0 {
+			return strconv.Itoa(*f)
+		}
+	case *float64:
+		if *f != 0 {
+			return strconv.FormatFloat(*f, 'g', -1, 64)
+		}
+	case *string:
+		return *f
+	case *[]int:
+		var s []string
+		for _, i := range *f {
+			s = append(s, strconv.Itoa(i))
+		}
+		return strings.Join(s, ",")
+	case *[]float64:
+		var s []string
+		for _, v := range *f {
+			s = append(s, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strings.Join(s, ",")
+	case *[]string:
+		return strings.Join(*f, ",")
+	case flag.Value:
+		if isBoolValue(f) && f.String() == "false" {
+			return ""
+		}
+		return f.String()
+	}
+	return ""
+}
+
+// valueName names the kind of value an option takes.
+func valueName(kind rune) string {
+	switch kind {
+	case 'i':
+		return "int"
+	case 'f':
+		return "float"
+	}
+	return "string"
+}
+
+// optionUsage renders how an option is given, such as "--[no]flag" or
+// "--length=int".  Only the first negation prefix is shown.  The parts are
+// returned separately so that callers can mark them up.
+func (oc *optionCollection) optionUsage(spec *optionSpec) (name, value string) {
+	name = "--" + spec.name
+	if prefixes := oc.negationPrefixes(); spec.negatable && len(prefixes) > 0 {
+		name = "--[" + prefixes[0] + "]" + spec.name
+	}
+	if spec.short != "" {
+		name = "-" + spec.short + ", " + name
+	}
+
+	switch spec.argType {
+	case optionRequiredArg:
+		value = "=" + valueName(spec.kind)
+	case optionOptionalArg:
+		value = "[=" + valueName(spec.kind) + "]"
+	}
+	return name, value
+}
+
+// optionNotes describes defaults and repetition as short sentences.
+func optionNotes(spec *optionSpec) []string {
+	var notes []string
+	if spec.def != "" && !spec.sensitive {
+		notes = append(notes, "Default: "+spec.def+".")
+	}
+	if spec.array || spec.counting {
+		notes = append(notes, "May be given more than once.")
+	}
+	if spec.split != nil {
+		notes = append(notes, fmt.Sprintf("Values may be separated by %q.", spec.split.sep))
+	}
+	return notes
+}
+
+// synopsis renders the arguments prog accepts.
+func (p *Parser) synopsis() string {
+	args := "[args...]"
+	if len(p.oc.params) > 0 {
+		args = p.oc.positionalSynopsis()
+	}
+	if len(p.oc.specs) == 0 {
+		return args
+	}
+	return "[options] [--] " + args
+}
+
+// roffEscape protects text from interpretation by roff.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, ".") || strings.HasPrefix(l, "'") {
+			lines[i] = `\&` + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteMan writes a section 1 man page in roff format for the program
+// prog to w.  summary is a short description for the NAME section.
+func (p *Parser) WriteMan(w io.Writer, prog, summary string) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, ".TH %s 1\n", roffEscape(strings.ToUpper(prog)))
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(prog), roffEscape(summary))
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", roffEscape(prog))
+	fmt.Fprintf(&b, "%s\n", roffEscape(p.synopsis()))
+
+	sections := p.oc.sections(Advanced)
+	if len(sections) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+	}
+	for _, s := range sections {
+		if s.title != "" {
+			fmt.Fprintf(&b, ".SS %s\n", roffEscape(s.title))
+		}
+		for _, spec := range s.specs {
+			name, value := p.oc.optionUsage(spec)
+			b.WriteString(".TP\n")
+			if value == "" {
+				fmt.Fprintf(&b, ".B %s\n", roffEscape(name))
+			} else {
+				fmt.Fprintf(&b, ".BI %s %s\n", roffEscape(name), roffEscape(value))
+			}
+			text := strings.TrimSpace(strings.Join(
+				append([]string{spec.help}, optionNotes(spec)...), " "))
+			if text != "" {
+				fmt.Fprintf(&b, "%s\n", roffEscape(text))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes a Markdown reference for the program prog to w.
+// summary is a short description to lead the document.
+func (p *Parser) WriteMarkdown(w io.Writer, prog, summary string) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", prog)
+	if summary != "" {
+		fmt.Fprintf(&b, "%s\n\n", summary)
+	}
+	b.WriteString("## Synopsis\n\n")
+	fmt.Fprintf(&b, "    %s %s\n", prog, p.synopsis())
+
+	sections := p.oc.sections(Advanced)
+	if len(sections) > 0 {
+		b.WriteString("\n## Options\n")
+	}
+	for _, s := range sections {
+		if s.title != "" {
+			fmt.Fprintf(&b, "\n### %s\n", s.title)
+		}
+		for _, spec := range s.specs {
+			name, value := p.oc.optionUsage(spec)
+			fmt.Fprintf(&b, "\n- `%s%s`", name, value)
+			if spec.help != "" {
+				fmt.Fprintf(&b, ": %s", spec.help)
+			}
+			b.WriteString("\n")
+			for _, note := range optionNotes(spec) {
+				fmt.Fprintf(&b, "  %s\n", note)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,121 @@
+package getopt
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func usageParser(t *testing.T) *Parser {
+	verbose := false
+	length := 24
+	files := []string{"a.conf"}
+	level := 0
+
+	p, err := NewParser(Config{},
+		"verbose!", &verbose, "length=i", &length,
+		"conf=s@", &files, "level:i", &level)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Describe("length", "Length of the thing."))
+	return p
+}
+
+func TestMan_Base(t *testing.T) {
+	p := usageParser(t)
+	var b bytes.Buffer
+
+	err := p.WriteMan(&b, "my-prog", "does things")
+
+	assert.NoError(t, err)
+	assert.Equal(t, b.String(), `.TH MY\-PROG 1
+.SH NAME
+my\-prog \- does things
+.SH SYNOPSIS
+.B my\-prog
+[options] [\-\-] [args...]
+.SH OPTIONS
+.TP
+.B \-\-[no]verbose
+.TP
+.BI \-\-length =int
+Length of the thing. Default: 24.
+.TP
+.BI \-\-conf =string
+Default: a.conf. May be given more than once.
+.TP
+.BI \-\-level [=int]
+`)
+}
+
+// Text which looks like roff requests is escaped.
+func TestMan_Escape(t *testing.T) {
+	p := usageParser(t)
+	assert.NoError(t, p.Describe("verbose", ".SH \\fBoops"))
+	var b bytes.Buffer
+
+	err := p.WriteMan(&b, "prog", "")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "\n\\&.SH \\efBoops\n")
+}
+
+func TestMarkdown_Base(t *testing.T) {
+	p := usageParser(t)
+	var b bytes.Buffer
+
+	err := p.WriteMarkdown(&b, "my-prog", "Does things.")
+
+	assert.NoError(t, err)
+	assert.Equal(t, b.String(), "# my-prog\n"+
+		"\n"+
+		"Does things.\n"+
+		"\n"+
+		"## Synopsis\n"+
+		"\n"+
+		"    my-prog [options] [--] [args...]\n"+
+		"\n"+
+		"## Options\n"+
+		"\n"+
+		"- `--[no]verbose`\n"+
+		"\n"+
+		"- `--length=int`: Length of the thing.\n"+
+		"  Default: 24.\n"+
+		"\n"+
+		"- `--conf=string`\n"+
+		"  Default: a.conf.\n"+
+		"  May be given more than once.\n"+
+		"\n"+
+		"- `--level[=int]`\n")
+}
+
+func TestMarkdown_NoOptions(t *testing.T) {
+	p, err := NewParser(Config{})
+	assert.NoError(t, err)
+	var b bytes.Buffer
+
+	err = p.WriteMarkdown(&b, "prog", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, b.String(), "# prog\n\n## Synopsis\n\n    prog [args...]\n")
+}
+
+func TestDescribe_Unknown(t *testing.T) {
+	p := usageParser(t)
+
+	err := p.Describe("other", "Not here.")
+
+	assert.ErrorContains(t, err, "not recognized")
+}
+
+// Negated names find the option they negate.
+func TestDescribe_Negated(t *testing.T) {
+	p := usageParser(t)
+	var b bytes.Buffer
+
+	err := p.Describe("noverbose", "Talk more.")
+	assert.NoError(t, err)
+	err = p.WriteMarkdown(&b, "prog", "")
+
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "- `--[no]verbose`: Talk more.\n")
+}